@@ -0,0 +1,341 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fox-audio/audio"
+	"fox-audio/display"
+	"fox-audio/model"
+	"fox-audio/reaper"
+	"fox-audio/util"
+)
+
+var (
+	// held while the transport is being changed
+	transportMutex sync.Mutex
+
+	// actions can come from the display and the control API at the same time
+	actionMutex sync.Mutex
+
+	// picked up by the disk writer, which performs the switch
+	newTakeChan chan bool
+)
+
+// handleAction carries out requests made from the display
+func handleAction(action display.Action, args []string) error {
+	actionMutex.Lock()
+	defer actionMutex.Unlock()
+
+	switch action {
+	case display.ActionSpectrumPort:
+		return setSpectrumPort(args)
+	case display.ActionRecord:
+		return setRecording(true)
+	case display.ActionPause:
+		return setRecording(false)
+	case display.ActionStop:
+		slog.Info("Stop requested, finishing session")
+		reaper.Reap()
+	case display.ActionNewTake:
+		return requestNewTake()
+	case display.ActionArm:
+		return armChannel(strings.Join(args, " "), true)
+	case display.ActionDisarm:
+		return armChannel(strings.Join(args, " "), false)
+	case display.ActionMarker:
+		return addMarker(strings.Join(args, " "))
+	}
+
+	return nil
+}
+
+func checkSession() error {
+	if getOutputFiles() == nil || reaper.Reaped() {
+		return errors.New("no recording session is running")
+	}
+
+	return nil
+}
+
+// setRecording pauses or resumes writing to the output files, the files stay
+// open while paused
+func setRecording(record bool) error {
+	if err := checkSession(); err != nil {
+		return err
+	}
+
+	if !transportMutex.TryLock() {
+		return errors.New("a new take is being started")
+	}
+	defer transportMutex.Unlock()
+
+	if transportRecord == record {
+		return nil
+	}
+
+	transportRecord = record
+
+	if record {
+		displayHandle.SetTransportStatus(display.StatusRecording)
+		slog.Info("Recording resumed")
+	} else {
+		displayHandle.SetTransportStatus(display.StatusPaused)
+		slog.Info("Recording paused")
+	}
+
+	driveTransport(record)
+
+	return nil
+}
+
+// armChannel arms or disarms the output file of a channel. Disarmed channels
+// are filled with silence so every file of the take stays the same length.
+func armChannel(value string, armed bool) error {
+	if err := checkSession(); err != nil {
+		return err
+	}
+
+	outputFile, err := findOutputFile(value)
+	if err != nil {
+		return err
+	}
+
+	if !outputFile.Enabled {
+		return errors.New("channel '" + outputFile.ChannelName + "' is disabled in the profile")
+	}
+
+	if !outputFile.IsActive() {
+		return errors.New("channel '" + outputFile.ChannelName + "' has failed and cannot be armed")
+	}
+
+	outputFile.SetStandby(!armed)
+
+	for i, port := range ports {
+		displayHandle.SetChannelArmStatus(i, port.IsArmed())
+	}
+
+	if armed {
+		slog.Info(fmt.Sprintf("Armed channel '%s'", outputFile.ChannelName))
+	} else {
+		slog.Info(fmt.Sprintf("Disarmed channel '%s'", outputFile.ChannelName))
+	}
+
+	return nil
+}
+
+// findOutputFile looks up an output file by a 1 based input port number or by
+// channel name
+func findOutputFile(value string) (*audio.OutputFile, error) {
+	if value == "" {
+		return nil, errors.New("expected an input number or channel name")
+	}
+
+	portNum, numErr := strconv.Atoi(value)
+
+	for _, outputFile := range getOutputFiles() {
+		if numErr != nil {
+			if strings.EqualFold(outputFile.ChannelName, value) {
+				return outputFile, nil
+			}
+
+			continue
+		}
+
+		for _, port := range outputFile.InputPorts {
+			if port != nil && port.GetNumber() == portNum {
+				return outputFile, nil
+			}
+		}
+	}
+
+	if numErr == nil {
+		return nil, errors.New("input " + value + " is not assigned to a channel")
+	}
+
+	return nil, errors.New("unknown channel: " + value)
+}
+
+func addMarker(name string) error {
+	if err := checkSession(); err != nil {
+		return err
+	}
+
+	frame := stats.framesProcessed.Load()
+
+	name, err := recordManifestMarker(frame, name)
+	if err != nil {
+		return err
+	}
+
+	position := float64(frame) / float64(audioServer.GetSampleRate())
+	slog.Info(fmt.Sprintf("Marker '%s' at %s", name, util.FormatDuration(position)))
+
+	return nil
+}
+
+func requestNewTake() error {
+	if err := checkSession(); err != nil {
+		return err
+	}
+
+	select {
+	case newTakeChan <- true:
+	default:
+		return errors.New("a new take is already being started")
+	}
+
+	return nil
+}
+
+// startNewTake closes the files of the current take and opens the files of the
+// next one. This runs on the disk writer so no write cycle can overlap it.
+func startNewTake(profile *model.Profile) {
+	if reaper.Reaped() {
+		return
+	}
+
+	// a profile reloaded on SIGHUP takes over from here
+	next := profile
+	reloaded := takePendingProfile()
+	if reloaded != nil {
+		next = reloaded
+	}
+
+	// {date} and {time} follow the start of each take
+	output, err := util.NextTake(next, time.Now())
+	if err != nil {
+		slog.Error("Failed to start a new take: " + err.Error())
+		restorePendingProfile(reloaded)
+		return
+	}
+
+	// timestamps only change once per second
+	if output.Take == profile.Output.Take && output.Directory == profile.Output.Directory {
+		slog.Warn("Take " + output.Take + " already exists, try again in a moment")
+		restorePendingProfile(reloaded)
+		return
+	}
+
+	transportMutex.Lock()
+	defer transportMutex.Unlock()
+
+	recording := transportRecord
+	transportRecord = false
+
+	// skip the cycles that finished before the transport stopped, then wait
+	// for the one that may still be filling the buffers
+	for len(cycleDoneChannel) > 0 {
+		<-cycleDoneChannel
+	}
+
+	select {
+	case <-cycleDoneChannel:
+	case <-time.After(1 * time.Second):
+	}
+
+	slog.Info("Finishing take " + profile.Output.Take)
+
+	writeCycle(profile, true)
+	audioServer.CloseOutputFiles()
+
+	for _, outputFile := range getOutputFiles() {
+		outputFile.Disarm()
+	}
+
+	// reading the files back takes a while on long takes, the next take
+	// doesn't wait for it
+	finished := endManifest()
+	takesFinishing.Add(1)
+
+	go func() {
+		defer reaper.HandlePanic()
+		defer takesFinishing.Done()
+
+		finishTake(finished)
+	}()
+
+	profileMutex.Lock()
+
+	if reloaded != nil {
+		applyProfile(profile, reloaded)
+	}
+
+	profile.Output.TakeTime = output.TakeTime
+	profile.Output.Take = output.Take
+	profile.Output.TakeNumber = output.TakeNumber
+	profile.Output.Directory = output.Directory
+
+	profileMutex.Unlock()
+
+	audioServer.PrepareOutputFiles()
+	setOutputFiles(audioServer.GetOutputFiles())
+
+	// preparing the files reaps the session if it fails
+	if reaper.Reaped() {
+		setExitCode(exitOutputFailed)
+		return
+	}
+
+	uiSetupOutputFiles()
+
+	for i, port := range ports {
+		displayHandle.SetChannelArmStatus(i, port.IsArmed())
+	}
+
+	newManifest(profile)
+
+	stats.framesProcessed.Store(0)
+	displayHandle.SetTakeName(profile.Output.Take)
+	displayHandle.SetDirectory(profile.Output.Directory)
+
+	transportRecord = recording
+	locateTransport()
+
+	slog.Info("Started take " + profile.Output.Take)
+}
+
+// applyProfile swaps a reloaded profile in between takes. Everything holds the
+// same profile pointer, so the contents are replaced rather than the pointer.
+// The caller must hold profileMutex.
+func applyProfile(profile *model.Profile, reloaded *model.Profile) {
+	// the audio server keeps running with the settings it was started with
+	if reloaded.AudioServer != profile.AudioServer {
+		slog.Warn("Audio server settings in the reloaded profile are ignored until fox is restarted")
+		reloaded.AudioServer = profile.AudioServer
+	}
+
+	*profile = *reloaded
+
+	displayHandle.SetProfileName(profile.Name)
+	displayHandle.SetChannelGroups(setupChannelGroups(profile))
+
+	slog.Info("Applied reloaded profile " + profile.Name)
+}
@@ -0,0 +1,199 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"fox-audio/audio"
+	"fox-audio/model"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	checksumFileSuffix = "_SHA256SUMS"
+)
+
+var (
+	verifyCmd = &cobra.Command{
+		Use:   "verify <dir>",
+		Short: "Verify the checksums of recorded files",
+		Args:  cobra.ExactArgs(1),
+
+		Run: func(cmd *cobra.Command, args []string) {
+			failed, err := verifyDirectory(args[0])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "verify failed: "+err.Error())
+				os.Exit(1)
+			}
+
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
+
+// writeChecksumFile writes a sha256sum compatible checksum file for the take.
+// This must only be called once the files of the take are verified.
+func writeChecksumFile(take *takeManifest) {
+	var sb strings.Builder
+
+	for _, outputFile := range take.files {
+		checksums := outputFile.GetChecksums()
+		if checksums == nil {
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("%s  %s\n", checksums.FileSha256, outputFile.FileName))
+	}
+
+	if sb.Len() == 0 {
+		return
+	}
+
+	checksumPath := path.Join(take.manifest.Directory, take.manifest.Take+checksumFileSuffix)
+
+	if err := os.WriteFile(checksumPath, []byte(sb.String()), 0644); err != nil {
+		slog.Error("Failed to write checksum file: " + err.Error())
+		return
+	}
+
+	slog.Info("Wrote checksum file " + checksumPath)
+}
+
+// verifyDirectory checks every file listed in the checksum files found in the
+// directory and returns the number of files that failed verification
+func verifyDirectory(dir string) (int, error) {
+	checksumFiles, err := filepath.Glob(path.Join(dir, "*"+checksumFileSuffix))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(checksumFiles) == 0 {
+		return 0, errors.New("no " + checksumFileSuffix + " files found in " + dir)
+	}
+
+	checked := 0
+	failed := 0
+
+	for _, checksumFile := range checksumFiles {
+		take := strings.TrimSuffix(path.Base(checksumFile), checksumFileSuffix)
+		expectedMd5 := readManifestMd5(path.Join(dir, take+manifestFileSuffix))
+
+		f, err := os.Open(checksumFile)
+		if err != nil {
+			return failed, err
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			expected, fileName, found := strings.Cut(scanner.Text(), "  ")
+			if !found {
+				continue
+			}
+
+			checked++
+
+			if !verifyFile(path.Join(dir, fileName), fileName, expected, expectedMd5[fileName]) {
+				failed++
+			}
+		}
+
+		err = scanner.Err()
+		f.Close()
+
+		if err != nil {
+			return failed, errors.New("failed to read " + checksumFile + ": " + err.Error())
+		}
+	}
+
+	fmt.Printf("%d of %d files verified, %d failed\n", checked-failed, checked, failed)
+
+	return failed, nil
+}
+
+// readManifestMd5 returns the audio md5 of every file that the take manifest
+// says has one embedded, takes recorded without a manifest have none
+func readManifestMd5(manifestPath string) map[string]string {
+	expected := make(map[string]string)
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return expected
+	}
+
+	var manifest model.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		fmt.Printf("%s: unreadable, not checking for missing md5 chunks (%s)\n", path.Base(manifestPath), err.Error())
+		return expected
+	}
+
+	for _, file := range manifest.Files {
+		if file.AudioMd5 != "" {
+			expected[file.FileName] = file.AudioMd5
+		}
+	}
+
+	return expected
+}
+
+func verifyFile(filePath string, fileName string, expected string, expectedMd5 string) bool {
+	checksums, err := audio.HashWavFile(filePath)
+	if err != nil {
+		fmt.Printf("%s: FAILED (%s)\n", fileName, err.Error())
+		return false
+	}
+
+	if checksums.FileSha256 != expected {
+		fmt.Printf("%s: FAILED (sha256 mismatch)\n", fileName)
+		return false
+	}
+
+	if expectedMd5 != "" && checksums.EmbeddedMd5 == "" {
+		fmt.Printf("%s: FAILED (embedded md5 missing)\n", fileName)
+		return false
+	}
+
+	if checksums.EmbeddedMd5 != "" && checksums.EmbeddedMd5 != checksums.AudioMd5 {
+		fmt.Printf("%s: FAILED (embedded md5 mismatch)\n", fileName)
+		return false
+	}
+
+	fmt.Printf("%s: OK\n", fileName)
+
+	return true
+}
@@ -45,26 +45,9 @@ var (
 			// util.DumpRunes(10500, 200)
 			// return
 
-			if cliArgs.ProfileName == "" {
-				slog.Error("Profile not specified but is REQUIRED. See fox --help for more info")
-				os.Exit(1)
-			}
-
-			config, err := util.ReadConfig(&cliArgs)
-			if err != nil {
-				slog.Error(fmt.Sprintf("failed to read config: %v", err))
-				os.Exit(1)
-			}
-
-			profile, err := util.ReadProfile(cliArgs.ProfileName)
-			if err != nil {
-				slog.Error(fmt.Sprintf("failed to read profile: %v", err))
-				os.Exit(1)
-			}
-
-			slog.Info(fmt.Sprintf("Configured log level: %d", config.LogLevel))
-
-			runEngine(config, profile)
+			config, profile := readSession()
+
+			os.Exit(runEngine(config, profile, newDisplay(config)))
 		},
 	}
 )
@@ -75,14 +58,67 @@ func init() {
 	rootCmd.Flags().BoolVar(&cliArgs.SimulateFreezeMeters, "simulate-freeze-meters", false, "Freeze the meters (don't randomly set level)")
 	rootCmd.Flags().IntVar(&cliArgs.SimulateChannelCount, "simulate-channel-count", 32, "Mumber of channels to simulate in UI test")
 
-	rootCmd.Flags().StringVarP(&cliArgs.ProfileName, "profile", "p", "default", "Name or path of the profile to load")
-	rootCmd.Flags().StringVarP(&cliArgs.ConfigFile, "config", "c", "fox.config", "Name or path of the config file to load")
+	rootCmd.Flags().StringVar(&cliArgs.OutputType, "output-type", "tui", "Output type (valid options: json, text, tui)")
 
-	rootCmd.Flags().StringVar(&cliArgs.OutputType, "output-type", "tui", "Output type (valid options: json, tui)")
+	addSessionFlags(rootCmd)
 
 	// TODO: implement empty file auto deletion
 }
 
+// addSessionFlags adds the flags shared by every command that records
+func addSessionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&cliArgs.ProfileName, "profile", "p", "default", "Name or path of the profile to load")
+	cmd.Flags().StringVarP(&cliArgs.ConfigFile, "config", "c", "fox.config", "Name or path of the config file to load")
+
+	// user defined template tokens
+	cmd.Flags().StringVar(&cliArgs.Event, "event", "", "Value for the {event} token in directory and filename templates")
+	cmd.Flags().StringVar(&cliArgs.Venue, "venue", "", "Value for the {venue} token in directory and filename templates")
+	cmd.Flags().StringToStringVar(&cliArgs.Tokens, "token", nil, "Additional template tokens, ex: --token band=Foo --token set=2")
+
+	cmd.Flags().StringVar(&cliArgs.ApiListen, "api-listen", "", "Serve the control API on this address, ex: 127.0.0.1:8080. Other addresses need an api token")
+	cmd.Flags().StringVar(&cliArgs.OscListen, "osc-listen", "", "Take OSC commands on this UDP address, ex: :9000")
+}
+
+// readSession loads the config and profile named on the command line, exiting
+// if either can't be used
+func readSession() (*model.Config, *model.Profile) {
+	if cliArgs.ProfileName == "" {
+		slog.Error("Profile not specified but is REQUIRED. See fox --help for more info")
+		os.Exit(exitInvalidConfig)
+	}
+
+	config, err := util.ReadConfig(&cliArgs)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to read config: %v", err))
+		os.Exit(exitInvalidConfig)
+	}
+
+	profile, err := util.ReadProfile(&cliArgs)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to read profile: %v", err))
+		os.Exit(exitInvalidConfig)
+	}
+
+	importConsoleNames(profile)
+
+	slog.Info(fmt.Sprintf("Configured log level: %d", config.LogLevel))
+
+	return config, profile
+}
+
+// importConsoleNames names the channels after the mixing console, if the
+// profile has one. This waits for the console to answer, so it is kept out
+// of reading the profile.
+func importConsoleNames(profile *model.Profile) {
+	if profile.Console.Address == "" {
+		return
+	}
+
+	if err := util.ImportConsoleNames(profile); err != nil {
+		slog.Warn("Unable to import channel names from the console, using the profile names: " + err.Error())
+	}
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -92,6 +128,6 @@ func Execute() {
 	err := rootCmd.Execute()
 
 	if err != nil {
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 }
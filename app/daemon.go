@@ -0,0 +1,207 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fox-audio/display"
+	"fox-audio/model"
+	"fox-audio/reaper"
+	"fox-audio/util"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	serviceStatusIntervalMs = 5000
+)
+
+var (
+	daemonLogFile string
+
+	// SIGHUP reloads the profile when running as a service, anywhere else it
+	// means the terminal went away and the session is finished
+	reloadOnHangup bool
+
+	// held while a reloaded profile replaces the contents of the current one
+	profileMutex sync.RWMutex
+
+	// set while a reload is reading the profile
+	reloading atomic.Bool
+
+	// a profile reloaded on SIGHUP, applied when the next take starts
+	pendingProfile      *model.Profile
+	pendingProfileMutex sync.Mutex
+
+	daemonCmd = &cobra.Command{
+		Use:   "daemon",
+		Short: "Record without a UI, for running as a system service",
+
+		Run: func(cmd *cobra.Command, args []string) {
+			config, profile := readSession()
+
+			// journald adds its own timestamps to whatever we write to stderr
+			output := os.Stderr
+			journal := true
+
+			if daemonLogFile != "" {
+				logFile, err := os.OpenFile(daemonLogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+				if err != nil {
+					slog.Error("failed to open log file: " + err.Error())
+					os.Exit(exitInvalidConfig)
+				}
+				output = logFile
+				journal = false
+			}
+
+			reloadOnHangup = true
+			exitCode := runEngine(config, profile, display.NewDaemonUI(output, journal))
+
+			if daemonLogFile != "" {
+				output.Close()
+			}
+
+			os.Exit(exitCode)
+		},
+	}
+)
+
+func init() {
+	daemonCmd.Flags().StringVar(&daemonLogFile, "log-file", "", "Append the log to this file instead of writing it to stderr for journald")
+
+	addSessionFlags(daemonCmd)
+
+	rootCmd.AddCommand(daemonCmd)
+}
+
+// setupServiceNotify keeps systemd up to date when fox runs as a notify
+// service, it does nothing otherwise
+func setupServiceNotify() {
+	if os.Getenv("NOTIFY_SOCKET") == "" {
+		return
+	}
+
+	if interval := util.SdWatchdogInterval(); interval > 0 {
+		processOnInterval("watchdog", stats.shutdownChan, int(interval.Milliseconds()/2), func() {
+			// only report in while the process callback keeps running
+			lastProcess := stats.jackProcessLastEndTime
+			if lastProcess == 0 || time.Now().UnixMicro()-lastProcess < interval.Microseconds() {
+				serviceNotify("WATCHDOG=1")
+			}
+		})
+	}
+
+	processOnInterval("service status", stats.shutdownChan, serviceStatusIntervalMs, func() {
+		serviceNotify("STATUS=" + serviceStatus())
+	})
+}
+
+// notifyReady tells systemd that recording has started
+func notifyReady() {
+	serviceNotify("READY=1\nSTATUS=" + serviceStatus())
+}
+
+func serviceNotify(state string) {
+	if err := util.SdNotify(state); err != nil {
+		slog.Debug("Failed to notify service manager: " + err.Error())
+	}
+}
+
+func serviceStatus() string {
+	if getOutputFiles() == nil {
+		return "Starting"
+	}
+
+	transport := "Paused"
+	if transportRecord {
+		transport = "Recording"
+	}
+
+	take := ""
+	if current := getManifest(); current != nil {
+		take = current.manifest.Take
+	}
+
+	return fmt.Sprintf("%s take %s, %s, %s, %d errors", transport, take, util.FormatDuration(stats.duration), util.FormatSize(stats.sessionSize), stats.errorCount.Load())
+}
+
+// reloadProfile reads the profile again, it takes effect when the next take
+// starts so the current files are left alone. This runs in the background so
+// waiting on the console doesn't hold up other signals.
+func reloadProfile() {
+	if !reloading.CompareAndSwap(false, true) {
+		slog.Warn("A profile reload is already in progress")
+		return
+	}
+
+	go func() {
+		defer reaper.HandlePanic()
+		defer reloading.Store(false)
+
+		profile, err := util.ReadProfile(&cliArgs)
+		if err != nil {
+			slog.Warn("Failed to reload profile, keeping the current one: " + err.Error())
+			return
+		}
+
+		importConsoleNames(profile)
+
+		pendingProfileMutex.Lock()
+		pendingProfile = profile
+		pendingProfileMutex.Unlock()
+
+		slog.Info("Reloaded profile " + profile.Name + ", it applies from the next take")
+	}()
+}
+
+// takePendingProfile returns the profile reloaded since the last take, if any
+func takePendingProfile() *model.Profile {
+	pendingProfileMutex.Lock()
+	defer pendingProfileMutex.Unlock()
+
+	profile := pendingProfile
+	pendingProfile = nil
+
+	return profile
+}
+
+// restorePendingProfile puts back a profile that could not be applied, unless
+// it has been reloaded again in the meantime
+func restorePendingProfile(profile *model.Profile) {
+	if profile == nil {
+		return
+	}
+
+	pendingProfileMutex.Lock()
+	defer pendingProfileMutex.Unlock()
+
+	if pendingProfile == nil {
+		pendingProfile = profile
+	}
+}
@@ -26,17 +26,28 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
+	"sync"
 	"time"
 
+	"fox-audio/audio"
 	"fox-audio/model"
 	"fox-audio/reaper"
 	"fox-audio/util"
 
-	"github.com/go-audio/audio"
+	goaudio "github.com/go-audio/audio"
+)
+
+var (
+	diskWriterDone chan bool
+
+	// takes whose files are still being verified in the background
+	takesFinishing sync.WaitGroup
 )
 
 func startDiskWriter(profile *model.Profile) {
 	reaper.Register("disk writer")
+	diskWriterDone = make(chan bool)
+	newTakeChan = make(chan bool, 1)
 
 	go diskWriter(profile)
 }
@@ -48,15 +59,23 @@ out:
 	for {
 		select {
 		case <-cycleDoneChannel:
+			// a failed cycle reaps the session, the remaining files are then
+			// flushed and closed once the reap is caught below
 			if !writeCycle(profile, false) {
-				break out
+				slog.Debug("diskwriter: write cycle failed, waiting for reap")
 			}
 
+		case <-newTakeChan:
+			startNewTake(profile)
+
 		default:
 			// waiting for data, check for reapage and sleep briefly
 			if reaper.Reaped() {
 				slog.Debug("diskwriter: reap caught, finish writing buffer")
 				writeCycle(profile, true)
+				audioServer.CloseOutputFiles()
+				finishTake(endManifest())
+				takesFinishing.Wait()
 				break out
 			}
 
@@ -64,6 +83,7 @@ out:
 		}
 	}
 
+	close(diskWriterDone)
 	reaper.Done("disk writer")
 }
 
@@ -73,8 +93,7 @@ func writeCycle(profile *model.Profile, finish bool) bool {
 	factor := float32(math.Pow(2, float64(profile.Output.BitDepth)-1)) - 1.0
 
 	// check if enough to write
-	if finish ||
-		(len(outputFiles) > 0 && len(outputFiles[0].GetWriteBuffers()[0]) > requiredSamples) {
+	if finish || bufferedSamples() > requiredSamples {
 
 		// disk load statistics
 		if stats.diskProcessLastEndTime > 0 {
@@ -84,8 +103,8 @@ func writeCycle(profile *model.Profile, finish bool) bool {
 		}
 		stats.diskProcessLastStartTime = time.Now().UnixMicro()
 
-		for _, outputFile := range outputFiles {
-			if !outputFile.Enabled {
+		for _, outputFile := range getOutputFiles() {
+			if !outputFile.IsActive() {
 				continue
 			}
 
@@ -106,29 +125,47 @@ func writeCycle(profile *model.Profile, finish bool) bool {
 				}
 
 				// allocate the encoder buffer
-				buf := &audio.IntBuffer{
+				buf := &goaudio.IntBuffer{
 					Data: make([]int, samplesToRead*outputFile.ChannelCount),
-					Format: &audio.Format{
+					Format: &goaudio.Format{
 						NumChannels: int(outputFile.ChannelCount),
 						SampleRate:  int(outputFile.SampleRate),
 					},
 				}
 
 				bufferIndex := 0
+				peak := float32(0)
+				clipped := uint64(0)
 
 				// loop through the samples, then the channel buffers in order to interleave the output
 				for sampleIndex := 0; sampleIndex < samplesToRead*outputFile.ChannelCount; sampleIndex += outputFile.ChannelCount {
 					for bufferIndex = 0; bufferIndex < outputFile.ChannelCount; bufferIndex++ {
+						sample := <-writeBuffers[bufferIndex]
+
+						if sample > peak {
+							peak = sample
+						} else if -sample > peak {
+							peak = -sample
+						}
+
+						if sample >= 1.0 || sample <= -1.0 {
+							clipped++
+						}
+
 						// for each sample we load, scale and cast to int
-						buf.Data[sampleIndex+bufferIndex] = int(<-writeBuffers[bufferIndex] * factor)
+						buf.Data[sampleIndex+bufferIndex] = int(sample * factor)
 					}
 				}
 
-				outputFile.Write(buf)
+				if err := outputFile.Write(buf); err != nil {
+					if !handleOutputFileError(profile, outputFile, err) {
+						return false
+					}
 
-				if finish {
-					outputFile.Close()
+					continue
 				}
+
+				outputFile.RecordWrite(uint64(samplesToRead), peak, clipped)
 			}
 		}
 
@@ -142,3 +179,44 @@ func writeCycle(profile *model.Profile, finish bool) bool {
 
 	return true
 }
+
+// bufferedSamples returns the number of samples waiting in the write buffer
+// of the first active output file
+func bufferedSamples() int {
+	for _, outputFile := range getOutputFiles() {
+		if outputFile.IsActive() {
+			return len(outputFile.GetWriteBuffers()[0])
+		}
+	}
+
+	return 0
+}
+
+// handleOutputFileError applies the profile error policy to a failed output
+// file. Returns false if the session should stop.
+func handleOutputFileError(profile *model.Profile, outputFile *audio.OutputFile, err error) bool {
+	slog.Error(err.Error())
+	setExitCode(exitOutputFailed)
+
+	// the failed file is skipped from here on, so it is closed now to patch
+	// its header with what made it to disk
+	if err := outputFile.Close(); err != nil {
+		slog.Error(err.Error())
+	}
+
+	if profile.Output.ErrorPolicy == model.ErrorPolicyStopAll {
+		reaper.Reap()
+		return false
+	}
+
+	slog.Warn(fmt.Sprintf("Continuing without channel '%s' per profile error policy", outputFile.ChannelName))
+
+	// stop the process callback from filling buffers nobody will read
+	outputFile.Disarm()
+
+	for i, port := range ports {
+		displayHandle.SetChannelArmStatus(i, port.IsArmed())
+	}
+
+	return true
+}
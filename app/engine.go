@@ -28,11 +28,12 @@ import (
 	"math"
 	"os"
 	"strconv"
-	"strings"
-	"time"
+	"sync"
+	"syscall"
 
 	"fox-audio/audio"
 	"fox-audio/display"
+	"fox-audio/metering"
 	"fox-audio/model"
 	"fox-audio/reaper"
 	"fox-audio/shared"
@@ -42,9 +43,26 @@ var (
 	displayHandle display.UI
 	audioServer   *audio.JackServer
 	ports         []*audio.Port
-	outputFiles   []*audio.OutputFile
+
+	// replaced by each new take, read through getOutputFiles
+	outputFiles      []*audio.OutputFile
+	outputFilesMutex sync.RWMutex
 )
 
+func getOutputFiles() []*audio.OutputFile {
+	outputFilesMutex.RLock()
+	defer outputFilesMutex.RUnlock()
+
+	return outputFiles
+}
+
+func setOutputFiles(files []*audio.OutputFile) {
+	outputFilesMutex.Lock()
+	defer outputFilesMutex.Unlock()
+
+	outputFiles = files
+}
+
 func ConfigureTextLogger() {
 	// text logger
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
@@ -72,7 +90,9 @@ func ConfigureFileLogger() {
 
 func ConfigureUiLogger(config *model.Config) {
 	handler := shared.NewTuiLogHandler(displayHandle, slog.Level(config.LogLevel), func(message string) {
+		stats.errorCount.Add(1)
 		displayHandle.IncrementErrorCount()
+		recordManifestError(message)
 	})
 	logger := slog.New(handler)
 	slog.SetDefault(logger)
@@ -81,15 +101,58 @@ func ConfigureUiLogger(config *model.Config) {
 	shared.EnableSlogLogging()
 }
 
-func runEngine(config *model.Config, profile *model.Profile) {
-	if config.OutputType == model.OutputTUI {
-		displayHandle = display.NewTui()
-	} else if config.OutputType == model.OutputJSON {
-		displayHandle = display.NewJsonUI(os.Stdout)
+func newDisplay(config *model.Config) display.UI {
+	if config.OutputType == model.OutputJSON {
+		return display.NewJsonUI(os.Stdout)
+	} else if config.OutputType == model.OutputText {
+		return display.NewTextUI(os.Stdout)
+	}
+
+	return display.NewTui()
+}
+
+// runEngine records until the session is stopped and returns the process
+// exit code
+func runEngine(config *model.Config, profile *model.Profile, ui display.UI) int {
+	uis := []display.UI{ui}
+
+	if config.Api.Listen != "" {
+		api := display.NewApiUI(config.Api)
+
+		if err := api.Listen(); err != nil {
+			slog.Error("failed to start control API: " + err.Error())
+			return exitListenFailed
+		}
+
+		uis = append(uis, api)
+	}
+
+	if config.Osc.Listen != "" || len(config.Osc.Feedback) > 0 {
+		oscUi := display.NewOscUI(config.Osc)
+
+		if err := oscUi.Listen(); err != nil {
+			slog.Error("failed to start OSC server: " + err.Error())
+			return exitListenFailed
+		}
+
+		uis = append(uis, oscUi)
+	}
+
+	if len(uis) > 1 {
+		ui = display.NewMultiUI(uis...)
 	}
 
+	displayHandle = ui
+
 	reaper.SetPanicHandler(displayHandle.HandlePanic)
 
+	if err := displayHandle.SetTheme(config.Theme); err != nil {
+		slog.Error(fmt.Sprintf("failed to load theme: %v", err))
+		return exitInvalidConfig
+	}
+
+	displayHandle.SetMeterStandard(config.MeteringOptions)
+	displayHandle.SetActionHandler(handleAction)
 	displayHandle.Initalize()
 	displayHandle.SetTransportStatus(display.StatusStarting)
 	displayHandle.Start()
@@ -98,14 +161,19 @@ func runEngine(config *model.Config, profile *model.Profile) {
 	statsShutdownChan := initStatistics(profile)
 	reaper.Callback("stats", func() { statsShutdownChan <- true })
 
-	// TODO: wait for user confirmation on error
-	// TODO: make this show a session summary instead
-	reaper.Callback("wait", func() { time.Sleep(3 * time.Second) })
+	reaper.Callback("summary", func() { showSummary(profile) })
+
+	setupServiceNotify()
 
-	shared.CatchSigint(func() {
-		slog.Info("Caught sigint, calling reaper")
+	shared.CatchSignals(func(sig os.Signal) {
+		if sig == syscall.SIGHUP && reloadOnHangup {
+			reloadProfile()
+			return
+		}
+
+		slog.Info("Caught " + sig.String() + ", calling reaper")
 		reaper.Reap()
-	})
+	}, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 
 	// ConfigureTextLogger()
 	ConfigureUiLogger(config)
@@ -123,6 +191,7 @@ func runEngine(config *model.Config, profile *model.Profile) {
 
 			if !connected {
 				slog.Error("Failed to connect to JACK server")
+				setExitCode(exitAudioServer)
 				reaper.Reap()
 			} else {
 				reaper.Callback("disconnect jack server", audioServer.Disconnect)
@@ -131,23 +200,37 @@ func runEngine(config *model.Config, profile *model.Profile) {
 
 				// only register input ports, for now
 				audioServer.RegisterPorts(true, false)
+				setupMidi(profile)
 
 				audioServer.PrepareOutputFiles()
-				outputFiles = audioServer.GetOutputFiles()
+				setOutputFiles(audioServer.GetOutputFiles())
+
+				// preparing the files reaps the session if it fails
+				if reaper.Reaped() {
+					setExitCode(exitOutputFailed)
+				}
 				uiSetupOutputFiles()
 
 				ports = audioServer.GetInputPorts()
-				uiSetupLevelMeters()
+				uiSetupLevelMeters(config, profile)
+				setupLoudness(profile)
+				setupSpectrum()
 
 				audioServer.ActivateClient()
 
 				startDiskWriter(profile)
 
 				audioServer.ConnectPorts(true, false)
+				connectMidi(profile)
 
 				uiSetOuputFormat(profile)
 
+				initManifest(profile)
+
 				transportRecord = true
+				setupTransport(profile)
+
+				notifyReady()
 			}
 		}
 	}
@@ -162,38 +245,50 @@ func runEngine(config *model.Config, profile *model.Profile) {
 
 	// wait for everything to finalize and shutdown
 	reaper.Wait()
+
+	return sessionExitCode()
 }
 
 func doShutdown() {
 	transportRecord = false
+	driveTransport(false)
 	displayHandle.SetTransportStatus(display.StatusShuttingDown)
+	serviceNotify("STOPPING=1")
 }
 
 func uiSetupOutputFiles() {
+	outputFiles := getOutputFiles()
+
 	uiOutputFiles := make([]model.UiOutputFile, len(outputFiles))
 	for ofIndex, outputFile := range outputFiles {
 
 		ports := make([]string, len(outputFile.InputPorts))
 		for pIndex, port := range outputFile.InputPorts {
-			jackPort := port.GetJackPort()
-
 			if port != nil {
-				ports[pIndex] = strings.Split(jackPort.GetName(), "_")[1]
+				ports[pIndex] = strconv.Itoa(port.GetNumber())
 			}
 		}
 
 		uiOutputFiles[ofIndex] = model.UiOutputFile{
-			Ports: ports,
-			Name:  outputFile.ChannelName,
-			Size:  0,
+			Ports:  ports,
+			Name:   outputFile.ChannelName,
+			Size:   0,
+			Health: outputFile.GetHealth(),
 		}
 	}
 	displayHandle.SetOutputFiles(uiOutputFiles)
 }
 
-func uiSetupLevelMeters() {
+func uiSetupLevelMeters(config *model.Config, profile *model.Profile) {
 	displayHandle.SetChannelCount(len(ports))
 	signalLevels = make([]model.SignalLevel, len(ports))
+	portMeters = make([]*metering.PortMeter, len(ports))
+
+	for i := range ports {
+		portMeters[i] = metering.NewPortMeter(audioServer.GetSampleRate(), config.MeteringOptions)
+	}
+
+	displayHandle.SetChannelGroups(setupChannelGroups(profile))
 
 	for i, port := range ports {
 		displayHandle.SetChannelArmStatus(i, port.IsArmed())
@@ -225,6 +320,7 @@ func getJackServer(profile *model.Profile) bool {
 		if err != nil {
 			slog.Error(err.Error())
 			displayHandle.SetTransportStatus(display.StatusFailed)
+			setExitCode(exitAudioServer)
 			jackRunning = false
 			reaper.Reap()
 		} else {
@@ -0,0 +1,58 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"sync/atomic"
+)
+
+// exit codes, so a service manager or script can tell why a session ended.
+// The failures follow sysexits.h, a panic exits with 2.
+const (
+	exitOk            = 0
+	exitSessionErrors = 1  // the session finished but errors were logged
+	exitUsage         = 64 // the command line could not be parsed
+	exitAudioServer   = 69 // the audio server could not be started or went away
+	exitListenFailed  = 71 // the control API or OSC server could not listen on its address
+	exitOutputFailed  = 74 // an output file could not be created or written
+	exitInvalidConfig = 78 // the config, profile or theme could not be used
+)
+
+var (
+	exitCode atomic.Int32
+)
+
+// setExitCode records why the session failed, the first failure wins
+func setExitCode(code int) {
+	exitCode.CompareAndSwap(exitOk, int32(code))
+}
+
+func sessionExitCode() int {
+	code := int(exitCode.Load())
+
+	if code == exitOk && stats.errorCount.Load() > 0 {
+		return exitSessionErrors
+	}
+
+	return code
+}
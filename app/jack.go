@@ -27,13 +27,14 @@ import (
 	"log/slog"
 	"time"
 
+	"fox-audio/metering"
 	"fox-audio/model"
 	"fox-audio/reaper"
-	"fox-audio/util"
 )
 
 var (
 	signalLevels     []model.SignalLevel
+	portMeters       []*metering.PortMeter
 	cycleDoneChannel chan bool
 
 	transportRecord bool
@@ -57,11 +58,18 @@ func jackInfo(message string) {
 
 func jackShutdown() {
 	slog.Info("JACK client: connection is shutting down")
+
+	// the server went away while we were still recording
+	if !reaper.Reaped() {
+		setExitCode(exitAudioServer)
+	}
+
 	transportRecord = false
 	reaper.Reap()
 }
 
 func jackXrun() int {
+	stats.xrunCount.Add(1)
 	slog.Error("JACK client: xrun occurred")
 
 	return 0
@@ -78,60 +86,95 @@ func jackProcess(nframes uint32) int {
 	stats.jackProcessLastStartTime = time.Now().UnixMicro()
 
 	if !reaper.Reaped() && transportRecord {
-		stats.framesProcessed += uint64(nframes)
+		stats.framesProcessed.Add(uint64(nframes))
 	}
 
+	processMidi(nframes)
+
 	// loop through the input channels
 	for portNum, port := range ports {
 
 		// get the incoming audio samples
 		samplesIn := port.GetJackBuffer(nframes)
-
-		sigLevel := float32(-1.0)
+		meter := portMeters[portNum]
+		loudnessInputs := portLoudness[portNum]
 
 		for frame := range nframes {
 			sample := float32(samplesIn[frame])
+			meter.AddSample(sample)
 
-			if sample > sigLevel {
-				sigLevel = sample
+			for _, input := range loudnessInputs {
+				input.AddSample(sample)
 			}
 		}
 
 		if !reaper.Reaped() {
-			signalLevels[portNum] = model.SignalLevel{
-				Instant: int(util.AmplitudeToDb(sigLevel)),
-			}
+			signalLevels[portNum] = meter.Level()
 
 			// TODO: make a transport class
 			if !transportRecord {
 				continue
 			}
 
-			if port.IsArmed() {
+			if port.IsAssigned() {
 				writeBuffer := port.GetWriteBuffer()
 				if cap(writeBuffer) > 0 {
 					if (len(writeBuffer) + int(nframes)) < cap(writeBuffer) {
 						// stats.samplesProcessed += uint64(nframes)
 
+						// disarmed channels are written as silence
+						armed := port.IsArmed()
+
 						for _, sample := range samplesIn {
-							writeBuffer <- float32(sample)
+							if armed {
+								writeBuffer <- float32(sample)
+							} else {
+								writeBuffer <- 0
+							}
 						}
 					} else {
+						stats.droppedSamples.Add(uint64(nframes))
 						slog.Error(fmt.Sprintf("%s: No space left in write buffer!!", port.GetJackPort().GetShortName()))
 					}
 				}
 			}
 		} else {
 			signalLevels[portNum] = model.SignalLevel{
-				Instant: -150,
+				Instant: metering.FloorDb,
+				Max:     metering.FloorDb,
+				Peak:    metering.FloorDb,
 			}
 		}
 	}
 
+	for _, stereoMeter := range stereoMeters {
+		pair := stereoMeter.Ports()
+		left := ports[pair[0]].GetJackBuffer(nframes)
+		right := ports[pair[1]].GetJackBuffer(nframes)
+
+		for frame := range nframes {
+			stereoMeter.AddFrame(float32(left[frame]), float32(right[frame]))
+		}
+
+		stereoMeter.Commit()
+	}
+
+	copySpectrumSamples(nframes)
+
+	// loudness is only integrated while recording
+	for _, loudnessMeter := range loudnessMeters {
+		loudnessMeter.Commit(transportRecord && !reaper.Reaped())
+	}
+
 	displayHandle.UpdateSignalLevels(signalLevels)
 
+	// the callback must never wait on the disk writer, a cycle it is too
+	// busy to be told about is picked up with the next one
 	if !reaper.Reaped() {
-		cycleDoneChannel <- true
+		select {
+		case cycleDoneChannel <- true:
+		default:
+		}
 	}
 
 	// audio load statistics
@@ -0,0 +1,80 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"fmt"
+	"log/slog"
+
+	"fox-audio/metering"
+	"fox-audio/model"
+	"fox-audio/reaper"
+	"fox-audio/util"
+)
+
+var (
+	loudnessMeters []*metering.LoudnessMeter
+
+	// loudness meter inputs fed by each input port, indexed like ports
+	portLoudness [][]*metering.LoudnessInput
+)
+
+// setupLoudness attaches the loudness meters defined in the profile to the
+// input ports. This must happen before the JACK client is activated.
+func setupLoudness(profile *model.Profile) {
+	portLoudness = make([][]*metering.LoudnessInput, len(ports))
+	names := make([]string, 0, len(profile.Loudness))
+
+	for i := range profile.Loudness {
+		source := &profile.Loudness[i]
+
+		// already validated when the profile was read
+		sourcePorts, _ := util.LoudnessPorts(profile, source)
+		meter := metering.NewLoudnessMeter(source.Name, audioServer.GetSampleRate(), audioServer.GetFramesPerPeriod(), len(sourcePorts))
+
+		for channel, portNum := range sourcePorts {
+			if portNum > len(ports) {
+				slog.Error(fmt.Sprintf("Input port '%d' specified by loudness meter '%s' does not exist", portNum, source.Name))
+				reaper.Reap()
+				return
+			}
+
+			portLoudness[portNum-1] = append(portLoudness[portNum-1], meter.Input(channel))
+		}
+
+		loudnessMeters = append(loudnessMeters, meter)
+		names = append(names, meter.Name())
+	}
+
+	displayHandle.SetLoudnessMeters(names)
+}
+
+func loudnessLevels() []model.LoudnessLevel {
+	levels := make([]model.LoudnessLevel, len(loudnessMeters))
+
+	for i, meter := range loudnessMeters {
+		levels[i] = meter.Level()
+	}
+
+	return levels
+}
@@ -0,0 +1,262 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"errors"
+	"log/slog"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"fox-audio/audio"
+	"fox-audio/model"
+	"fox-audio/util"
+)
+
+const (
+	manifestVersion    = 1
+	manifestIntervalMs = 5000
+	manifestFileSuffix = "_take.json"
+)
+
+var (
+	currentManifest *takeManifest
+	manifestMutex   sync.Mutex
+)
+
+// takeManifest is the manifest of one take along with the files it lists
+type takeManifest struct {
+	mutex    sync.Mutex
+	path     string
+	manifest *model.Manifest
+	files    []*audio.OutputFile
+}
+
+func initManifest(profile *model.Profile) {
+	newManifest(profile)
+
+	processOnInterval("manifest", stats.shutdownChan, manifestIntervalMs, func() {
+		if take := getManifest(); take != nil {
+			take.write(false)
+		}
+	})
+}
+
+// newManifest starts the manifest for the current take
+func newManifest(profile *model.Profile) {
+	take := newTakeManifest(profile, getOutputFiles(), audioServer.GetSampleRate(), audioServer.GetFramesPerPeriod())
+
+	manifestMutex.Lock()
+	currentManifest = take
+	manifestMutex.Unlock()
+}
+
+func getManifest() *takeManifest {
+	manifestMutex.Lock()
+	defer manifestMutex.Unlock()
+
+	return currentManifest
+}
+
+func recordManifestError(message string) {
+	if take := getManifest(); take != nil {
+		take.recordError(message)
+	}
+}
+
+// recordManifestMarker stores a position in the take, frame is counted from
+// the start of the take. Unnamed markers are numbered.
+func recordManifestMarker(frame uint64, name string) (string, error) {
+	take := getManifest()
+	if take == nil {
+		return "", errors.New("no take is being recorded")
+	}
+
+	return take.recordMarker(frame, name)
+}
+
+// endManifest stops recording markers and errors to the current take and
+// returns its manifest, so the take can be finished
+func endManifest() *takeManifest {
+	manifestMutex.Lock()
+	defer manifestMutex.Unlock()
+
+	take := currentManifest
+	currentManifest = nil
+
+	return take
+}
+
+// finishTake reads the closed files of a take back to verify them, then
+// writes the checksum file and the final manifest
+func finishTake(take *takeManifest) {
+	if take == nil {
+		return
+	}
+
+	for _, outputFile := range take.files {
+		if err := outputFile.Verify(); err != nil {
+			slog.Error(err.Error())
+		}
+	}
+
+	writeChecksumFile(take)
+	take.write(true)
+}
+
+func newTakeManifest(profile *model.Profile, files []*audio.OutputFile, sampleRate int, framesPerPeriod int) *takeManifest {
+	// a reload replaces the profile while the take is still being written
+	profileCopy := *profile
+
+	return &takeManifest{
+		path:  path.Join(profile.Output.Directory, profile.Output.Take+manifestFileSuffix),
+		files: files,
+		manifest: &model.Manifest{
+			Version:         manifestVersion,
+			ProfileName:     profile.Name,
+			Take:            profile.Output.Take,
+			TakeNumber:      profile.Output.TakeNumber,
+			Directory:       profile.Output.Directory,
+			SampleRate:      sampleRate,
+			FramesPerPeriod: framesPerPeriod,
+			BitDepth:        profile.Output.BitDepth,
+			StartTime:       time.Now(),
+			Errors:          make([]model.ManifestError, 0),
+			Markers:         make([]model.ManifestMarker, 0),
+			Profile:         &profileCopy,
+		},
+	}
+}
+
+func (take *takeManifest) recordError(message string) {
+	take.mutex.Lock()
+	defer take.mutex.Unlock()
+
+	if take.manifest.Finalized {
+		return
+	}
+
+	take.manifest.Errors = append(take.manifest.Errors, model.ManifestError{
+		Time:    time.Now(),
+		Message: message,
+	})
+}
+
+func (take *takeManifest) recordMarker(frame uint64, name string) (string, error) {
+	take.mutex.Lock()
+	defer take.mutex.Unlock()
+
+	if take.manifest.Finalized {
+		return "", errors.New("no take is being recorded")
+	}
+
+	if name == "" {
+		name = "Marker " + strconv.Itoa(len(take.manifest.Markers)+1)
+	}
+
+	take.manifest.Markers = append(take.manifest.Markers, model.ManifestMarker{
+		Time:  time.Now(),
+		Frame: frame,
+		Name:  name,
+	})
+
+	return name, nil
+}
+
+func (take *takeManifest) write(finalize bool) {
+	take.mutex.Lock()
+
+	if take.manifest.Finalized {
+		take.mutex.Unlock()
+		return
+	}
+
+	take.update(finalize)
+	err := util.WriteJsonFile(take.path, take.manifest)
+
+	// an error that is logged is recorded in the manifest, so the lock has
+	// to be released first
+	take.mutex.Unlock()
+
+	if err != nil {
+		slog.Error("Failed to write take manifest: " + err.Error())
+		return
+	}
+
+	if finalize {
+		slog.Info("Wrote take manifest " + take.path)
+	}
+}
+
+// update copies the current state of the files into the manifest, the caller
+// must hold the mutex
+func (take *takeManifest) update(finalize bool) {
+	manifest := take.manifest
+
+	manifest.UpdatedTime = time.Now()
+	manifest.XrunCount = int(stats.xrunCount.Load())
+	manifest.Files = make([]model.ManifestFile, len(take.files))
+
+	for i, outputFile := range take.files {
+		fileStats := outputFile.GetStats()
+
+		file := model.ManifestFile{
+			ChannelName: outputFile.ChannelName,
+			FileName:    outputFile.FileName,
+			Ports:       make([]string, 0, len(outputFile.InputPorts)),
+			Health:      model.FileHealthNames[outputFile.GetHealth()],
+			Bytes:       fileStats.WrittenBytes,
+			Samples:     fileStats.FramesWritten,
+			PeakDbfs:    util.AmplitudeToDbFloor(fileStats.PeakAmplitude, -150),
+		}
+
+		for _, port := range outputFile.InputPorts {
+			if port != nil {
+				file.Ports = append(file.Ports, port.GetSourceName())
+			}
+		}
+
+		if err := outputFile.GetLastError(); err != nil {
+			file.Error = err.Error()
+		}
+
+		if checksums := outputFile.GetChecksums(); checksums != nil {
+			file.Sha256 = checksums.FileSha256
+			file.AudioSha256 = checksums.AudioSha256
+
+			if checksums.EmbeddedMd5 != "" {
+				file.AudioMd5 = checksums.AudioMd5
+			}
+		}
+
+		manifest.Files[i] = file
+	}
+
+	if finalize {
+		stopTime := time.Now()
+		manifest.StopTime = &stopTime
+		manifest.Finalized = true
+	}
+}
@@ -0,0 +1,218 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fox-audio/audio"
+	"fox-audio/model"
+)
+
+func readTestManifest(t *testing.T, manifestPath string) *model.Manifest {
+	t.Helper()
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := &model.Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	return manifest
+}
+
+func TestTakeManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	profile := &model.Profile{
+		Name: "live",
+		Output: model.ProfileOutput{
+			Directory:  dir,
+			Take:       "B",
+			TakeNumber: 2,
+			BitDepth:   24,
+		},
+	}
+
+	kick := &audio.OutputFile{ChannelName: "Kick", FileName: "B_kick.wav", Enabled: true}
+	kick.RecordWrite(48000, 0.5, 0)
+	kick.RecordWrite(24000, 0.25, 3)
+
+	snare := &audio.OutputFile{ChannelName: "Snare", FileName: "B_snare.wav", Enabled: true}
+
+	take := newTakeManifest(profile, []*audio.OutputFile{kick, snare}, 48000, 256)
+
+	// the manifest keeps the profile the take was started with
+	profile.Name = "reloaded"
+
+	if name, err := take.recordMarker(48000, ""); err != nil || name != "Marker 1" {
+		t.Fatalf("recordMarker = %q, %v", name, err)
+	}
+
+	if name, err := take.recordMarker(72000, "chorus"); err != nil || name != "chorus" {
+		t.Fatalf("recordMarker = %q, %v", name, err)
+	}
+
+	take.recordError("disk is slow")
+	take.write(false)
+
+	manifestPath := filepath.Join(dir, "B"+manifestFileSuffix)
+	manifest := readTestManifest(t, manifestPath)
+
+	if manifest.Version != manifestVersion || manifest.Take != "B" || manifest.TakeNumber != 2 || manifest.SampleRate != 48000 || manifest.FramesPerPeriod != 256 || manifest.BitDepth != 24 {
+		t.Errorf("unexpected take details: %+v", manifest)
+	}
+
+	if manifest.ProfileName != "live" || manifest.Profile == nil || manifest.Profile.Name != "live" {
+		t.Errorf("profile changed after the take started: %q, %+v", manifest.ProfileName, manifest.Profile)
+	}
+
+	if manifest.Finalized || manifest.StopTime != nil {
+		t.Error("manifest finalized before the take finished")
+	}
+
+	if len(manifest.Markers) != 2 || manifest.Markers[0].Frame != 48000 || manifest.Markers[1].Name != "chorus" {
+		t.Errorf("unexpected markers: %+v", manifest.Markers)
+	}
+
+	if len(manifest.Errors) != 1 || manifest.Errors[0].Message != "disk is slow" {
+		t.Errorf("unexpected errors: %+v", manifest.Errors)
+	}
+
+	if len(manifest.Files) != 2 {
+		t.Fatalf("got %d files, want 2", len(manifest.Files))
+	}
+
+	if file := manifest.Files[0]; file.FileName != "B_kick.wav" || file.Samples != 72000 || file.PeakDbfs < -6.03 || file.PeakDbfs > -6.01 || file.Health != "ok" {
+		t.Errorf("unexpected kick file: %+v", file)
+	}
+
+	if file := manifest.Files[1]; file.ChannelName != "Snare" || file.Samples != 0 || file.PeakDbfs != -150 {
+		t.Errorf("unexpected snare file: %+v", file)
+	}
+
+	// counters keep going until the take is finalized
+	snare.RecordWrite(1000, 1, 10)
+	take.write(true)
+
+	manifest = readTestManifest(t, manifestPath)
+
+	if !manifest.Finalized || manifest.StopTime == nil || manifest.StopTime.Before(manifest.StartTime) {
+		t.Errorf("manifest not finalized: finalized %v, stop %v", manifest.Finalized, manifest.StopTime)
+	}
+
+	if manifest.Files[1].Samples != 1000 || manifest.Files[1].PeakDbfs != 0 {
+		t.Errorf("final counters missing: %+v", manifest.Files[1])
+	}
+
+	// nothing changes once the manifest is final
+	final, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	take.recordError("too late")
+	if _, err := take.recordMarker(96000, "late"); err == nil {
+		t.Error("expected a marker on a finalized take to fail")
+	}
+
+	kick.RecordWrite(48000, 1, 0)
+	take.write(false)
+	take.write(true)
+
+	after, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(after) != string(final) {
+		t.Error("finalized manifest was written again")
+	}
+}
+
+// errorRecorder records logged errors in the manifest, like the UI logger
+type errorRecorder struct {
+	slog.Handler
+}
+
+func (h errorRecorder) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelError {
+		recordManifestError(record.Message)
+	}
+
+	return nil
+}
+
+func TestTakeManifestWriteError(t *testing.T) {
+	profile := &model.Profile{
+		Output: model.ProfileOutput{
+			Directory: filepath.Join(t.TempDir(), "missing"),
+			Take:      "A",
+		},
+	}
+
+	take := newTakeManifest(profile, nil, 48000, 256)
+
+	manifestMutex.Lock()
+	previous := currentManifest
+	currentManifest = take
+	manifestMutex.Unlock()
+
+	logger := slog.Default()
+	slog.SetDefault(slog.New(errorRecorder{slog.Default().Handler()}))
+
+	t.Cleanup(func() {
+		slog.SetDefault(logger)
+
+		manifestMutex.Lock()
+		currentManifest = previous
+		manifestMutex.Unlock()
+	})
+
+	done := make(chan bool)
+
+	go func() {
+		take.write(false)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writing the manifest deadlocked while logging its error")
+	}
+
+	if len(take.manifest.Errors) != 1 {
+		t.Fatalf("got %d errors, want the failed write", len(take.manifest.Errors))
+	}
+}
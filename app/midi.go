@@ -0,0 +1,247 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"fmt"
+	"log/slog"
+
+	"fox-audio/audio"
+	"fox-audio/display"
+	"fox-audio/model"
+	"fox-audio/reaper"
+)
+
+const (
+	midiPortName = "midi_in"
+
+	// actions waiting to be carried out, more than this in a single burst
+	// are dropped
+	midiQueueSize = 16
+)
+
+var (
+	midiPort     *audio.MidiPort
+	midiBindings *midiBindingTable
+	midiQueue    chan midiAction
+
+	midiActions = map[string]display.Action{
+		model.MidiActionRecord:  display.ActionRecord,
+		model.MidiActionPause:   display.ActionPause,
+		model.MidiActionStop:    display.ActionStop,
+		model.MidiActionNewTake: display.ActionNewTake,
+		model.MidiActionMarker:  display.ActionMarker,
+		model.MidiActionArm:     display.ActionArm,
+		model.MidiActionDisarm:  display.ActionDisarm,
+	}
+)
+
+type midiAction struct {
+	name   string
+	action display.Action
+	args   []string
+}
+
+// midiBindingTable is built from the profile when the session starts, so the
+// process callback never reads the profile while a reload replaces it
+type midiBindingTable struct {
+	deviceId byte
+	mmc      map[byte]*midiAction
+	notes    []midiBinding
+	ccs      []midiBinding
+
+	// last value of each controller by channel, only touched by the process
+	// callback
+	ccValues [16][128]byte
+}
+
+type midiBinding struct {
+	channel int // 1 based, 0 matches every channel
+	number  byte
+	action  *midiAction
+}
+
+// setupMidi registers the MIDI input port, it must be called before the client
+// is activated
+func setupMidi(profile *model.Profile) {
+	if !profile.Midi.Enable {
+		return
+	}
+
+	midiBindings = newMidiBindingTable(&profile.Midi)
+	midiPort = audioServer.RegisterMidiInput(midiPortName)
+
+	if midiPort == nil {
+		return
+	}
+
+	midiQueue = make(chan midiAction, midiQueueSize)
+	midiDone := make(chan bool)
+
+	go func() {
+		defer reaper.HandlePanic()
+
+		for {
+			select {
+			case <-midiDone:
+				return
+			case queued := <-midiQueue:
+				slog.Debug("MIDI: " + queued.name)
+
+				if err := handleAction(queued.action, queued.args); err != nil {
+					slog.Warn("MIDI " + queued.name + " failed: " + err.Error())
+				}
+			}
+		}
+	}()
+
+	reaper.Callback("midi", func() { close(midiDone) })
+}
+
+// connectMidi connects the configured sources, it must be called after the
+// client is activated
+func connectMidi(profile *model.Profile) {
+	if midiPort != nil {
+		audioServer.ConnectMidiInput(midiPort, profile.Midi.Connect)
+	}
+}
+
+// processMidi runs in the process callback, so matched actions are only queued
+func processMidi(nframes uint32) {
+	if midiPort == nil {
+		return
+	}
+
+	events := midiPort.GetEvents(nframes)
+
+	for i := range events {
+		queued := midiBindings.match(events[i].Data[:events[i].Size])
+		if queued == nil {
+			continue
+		}
+
+		select {
+		case midiQueue <- *queued:
+		default:
+		}
+	}
+}
+
+func newMidiBindingTable(midi *model.ProfileMidi) *midiBindingTable {
+	table := &midiBindingTable{
+		deviceId: byte(midi.MmcDeviceId),
+		mmc:      make(map[byte]*midiAction),
+		notes:    make([]midiBinding, 0),
+		ccs:      make([]midiBinding, 0),
+	}
+
+	for command, name := range midi.Mmc {
+		if name == "" {
+			continue
+		}
+
+		table.mmc[model.MmcCommands[command]] = newMidiAction("mmc "+command, name, "")
+	}
+
+	for _, mapping := range midi.Mappings {
+		binding := midiBinding{
+			channel: mapping.Channel,
+			number:  byte(mapping.Number),
+			action:  newMidiAction(fmt.Sprintf("%s %d", mapping.Type, mapping.Number), mapping.Action, mapping.Argument),
+		}
+
+		if mapping.Type == model.MidiTypeNote {
+			table.notes = append(table.notes, binding)
+		} else {
+			table.ccs = append(table.ccs, binding)
+		}
+	}
+
+	return table
+}
+
+func newMidiAction(source string, name string, argument string) *midiAction {
+	queued := &midiAction{
+		name:   source + " -> " + name,
+		action: midiActions[name],
+		args:   make([]string, 0),
+	}
+
+	if argument != "" {
+		queued.args = append(queued.args, argument)
+	}
+
+	return queued
+}
+
+// match returns the action for a MIDI message, or nil. Notes trigger on note
+// on and CCs when they cross into the upper half, so releasing a footswitch or
+// moving a fader around the top does nothing.
+func (table *midiBindingTable) match(message []byte) *midiAction {
+	if len(message) == 0 {
+		return nil
+	}
+
+	// MMC: F0 7F <device> 06 <command> ... F7
+	if message[0] == 0xf0 {
+		if len(message) < 6 || message[1] != 0x7f || message[3] != 0x06 {
+			return nil
+		}
+
+		if message[2] != table.deviceId && message[2] != model.MmcAllCall {
+			return nil
+		}
+
+		return table.mmc[message[4]]
+	}
+
+	if len(message) < 3 {
+		return nil
+	}
+
+	status := message[0] & 0xf0
+	channel := int(message[0]&0x0f) + 1
+	number := message[1] & 0x7f
+	value := message[2]
+
+	var bindings []midiBinding
+
+	if status == 0x90 && value > 0 {
+		bindings = table.notes
+	} else if status == 0xb0 {
+		previous := table.ccValues[channel-1][number]
+		table.ccValues[channel-1][number] = value
+
+		if value >= 64 && previous < 64 {
+			bindings = table.ccs
+		}
+	}
+
+	for _, binding := range bindings {
+		if binding.number == number && (binding.channel == 0 || binding.channel == channel) {
+			return binding.action
+		}
+	}
+
+	return nil
+}
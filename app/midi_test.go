@@ -0,0 +1,105 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"testing"
+
+	"fox-audio/model"
+)
+
+func TestMidiBindingMatch(t *testing.T) {
+	midi := &model.ProfileMidi{
+		MmcDeviceId: 5,
+		Mmc: map[string]string{
+			"stop":   model.MidiActionPause,
+			"play":   model.MidiActionRecord,
+			"locate": "",
+		},
+		Mappings: []model.ProfileMidiMapping{
+			{Type: model.MidiTypeNote, Channel: 1, Number: 60, Action: model.MidiActionMarker, Argument: "verse"},
+			{Type: model.MidiTypeCc, Channel: 0, Number: 64, Action: model.MidiActionNewTake},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		messages [][]byte
+		want     string // action name of the last message, empty for no match
+	}{
+		{"mmc stop", [][]byte{{0xf0, 0x7f, 0x05, 0x06, 0x01, 0xf7}}, "mmc stop -> pause"},
+		{"mmc play all call", [][]byte{{0xf0, 0x7f, 0x7f, 0x06, 0x02, 0xf7}}, "mmc play -> record"},
+		{"mmc other device", [][]byte{{0xf0, 0x7f, 0x06, 0x06, 0x01, 0xf7}}, ""},
+		{"mmc locate ignored", [][]byte{{0xf0, 0x7f, 0x05, 0x06, 0x44, 0x06, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf7}}, ""},
+		{"mmc unbound command", [][]byte{{0xf0, 0x7f, 0x05, 0x06, 0x09, 0xf7}}, ""},
+		{"mmc truncated", [][]byte{{0xf0, 0x7f, 0x05, 0x06, 0x01}}, ""},
+		{"non realtime sysex", [][]byte{{0xf0, 0x7e, 0x05, 0x06, 0x01, 0xf7}}, ""},
+		{"note on", [][]byte{{0x90, 60, 100}}, "note 60 -> marker"},
+		{"note on other channel", [][]byte{{0x91, 60, 100}}, ""},
+		{"note on zero velocity", [][]byte{{0x90, 60, 0}}, ""},
+		{"note off", [][]byte{{0x80, 60, 100}}, ""},
+		{"cc rising", [][]byte{{0xb3, 64, 127}}, "cc 64 -> new_take"},
+		{"cc held high", [][]byte{{0xb3, 64, 127}, {0xb3, 64, 100}}, ""},
+		{"cc released and pressed", [][]byte{{0xb3, 64, 127}, {0xb3, 64, 0}, {0xb3, 64, 127}}, "cc 64 -> new_take"},
+		{"cc below threshold", [][]byte{{0xb0, 64, 63}}, ""},
+		{"short message", [][]byte{{0x90, 60}}, ""},
+		{"empty message", [][]byte{{}}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table := newMidiBindingTable(midi)
+
+			var matched *midiAction
+			for _, message := range test.messages {
+				matched = table.match(message)
+			}
+
+			got := ""
+			if matched != nil {
+				got = matched.name
+			}
+
+			if got != test.want {
+				t.Fatalf("match = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMidiBindingArgument(t *testing.T) {
+	table := newMidiBindingTable(&model.ProfileMidi{
+		Mappings: []model.ProfileMidiMapping{
+			{Type: model.MidiTypeNote, Number: 36, Action: model.MidiActionDisarm, Argument: "1"},
+		},
+	})
+
+	matched := table.match([]byte{0x99, 36, 1})
+	if matched == nil {
+		t.Fatal("expected a match on any channel")
+	}
+
+	if matched.action != midiActions[model.MidiActionDisarm] || len(matched.args) != 1 || matched.args[0] != "1" {
+		t.Fatalf("unexpected action %+v", matched)
+	}
+}
@@ -52,10 +52,14 @@ func startSimulation(simulationOptions *model.SimulationOptions) {
 			}
 
 			for channel := range simulationOptions.ChannelCount {
-				newLevel := (rand.IntN(70) + 0) * (-1)
+				newLevel := float64(rand.IntN(70) * (-1))
+				newPeak := min(newLevel+float64(rand.IntN(10)+3), 0)
 
 				levels[channel] = model.SignalLevel{
-					Instant: int(newLevel),
+					Instant: newLevel,
+					Max:     newPeak,
+					Meter:   newPeak,
+					Peak:    max(levels[channel].Peak-1, newPeak),
 				}
 			}
 
@@ -0,0 +1,78 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"errors"
+	"strconv"
+
+	"fox-audio/metering"
+)
+
+var (
+	spectrumAnalyzer *metering.SpectrumAnalyzer
+)
+
+func setupSpectrum() {
+	spectrumAnalyzer = metering.NewSpectrumAnalyzer(audioServer.GetSampleRate())
+}
+
+// setSpectrumPort moves the analyzer to another port, args[0] is the 0 based
+// port index or -1 to stop analyzing
+func setSpectrumPort(args []string) error {
+	if len(args) != 1 {
+		return errors.New("spectrum port expects 1 argument")
+	}
+
+	portNum, err := strconv.Atoi(args[0])
+	if err != nil || portNum < -1 || portNum >= len(ports) {
+		return errors.New("invalid spectrum port: " + args[0])
+	}
+
+	if spectrumAnalyzer == nil {
+		return nil
+	}
+
+	spectrumAnalyzer.SetPort(-1)
+	spectrumAnalyzer.Reset()
+	spectrumAnalyzer.SetPort(portNum)
+
+	return nil
+}
+
+// copySpectrumSamples hands the selected port to the analyzer, this runs on
+// the process thread so it never waits for space
+func copySpectrumSamples(nframes uint32) {
+	if spectrumAnalyzer == nil {
+		return
+	}
+
+	portNum := spectrumAnalyzer.Port()
+	if portNum < 0 || portNum >= len(ports) || !spectrumAnalyzer.HasSpace(int(nframes)) {
+		return
+	}
+
+	for _, sample := range ports[portNum].GetJackBuffer(nframes) {
+		spectrumAnalyzer.AddSample(float32(sample))
+	}
+}
@@ -24,7 +24,9 @@ package app
 
 import (
 	"fmt"
+	"log/slog"
 	"math"
+	"sync/atomic"
 	"time"
 
 	"fox-audio/model"
@@ -52,7 +54,12 @@ type statistics struct {
 	diskProcessIdleChan      chan int64
 
 	shutdownChan    chan bool
-	framesProcessed uint64
+	framesProcessed atomic.Uint64
+	xrunCount       atomic.Int64
+	droppedSamples  atomic.Uint64
+	errorCount      atomic.Int64
+	duration        float64
+	sessionSize     uint64
 	// samplesProcessed uint64
 
 	diskPerformance   []float64
@@ -87,17 +94,21 @@ func initStatistics(profile *model.Profile) chan bool {
 	// session size
 	processOnInterval("session size stats", stats.shutdownChan, 500, func() {
 		usedBytes := uint64(0)
+		outputFiles := getOutputFiles()
 
 		outputFileSizes := make([]uint64, len(outputFiles))
+		outputFileHealth := make([]model.FileHealth, len(outputFiles))
 		for i, outputFile := range outputFiles {
-			if outputFile.Encoder != nil {
-				outputFileSizes[i] = uint64(outputFile.Encoder.WrittenBytes)
-				usedBytes += uint64(outputFile.Encoder.WrittenBytes)
-			}
+			outputFileSizes[i] = outputFile.GetStats().WrittenBytes
+			usedBytes += outputFileSizes[i]
+
+			outputFileHealth[i] = outputFile.GetHealth()
 		}
 
 		displayHandle.UpdateOutputFileSizes(outputFileSizes)
+		displayHandle.UpdateOutputFileHealth(outputFileHealth)
 		displayHandle.SetSessionSize(usedBytes)
+		stats.sessionSize = usedBytes
 
 		// get bytes read from jack
 		// usedBytesRaw := (stats.samplesProcessed * uint64(profile.Output.BitDepth)) / 8
@@ -109,19 +120,59 @@ func initStatistics(profile *model.Profile) chan bool {
 
 	// disk space utilization
 	processOnInterval("disk space", stats.shutdownChan, 5000, func() {
-		diskInfo := util.GetDiskSpace(profile.Output.Directory)
+		profileMutex.RLock()
+		directory := profile.Output.Directory
+		profileMutex.RUnlock()
+
+		diskInfo := util.GetDiskSpace(directory)
 		displayHandle.SetDiskUsage(int(math.Round(diskInfo.UsedPct * 100.0)))
 
 		util.TraceLog(fmt.Sprintf("Disk total: %d B, Disk Used: %d B, Disk free: %d B, used %0.2f%%", diskInfo.Size, diskInfo.Used, diskInfo.Free, diskInfo.UsedPct*100.0))
 	})
 
+	// clip events are counted on the process thread and logged from here
+	clipsLogged := make([]uint64, 0)
+	processOnInterval("clip log", stats.shutdownChan, 250, func() {
+		if len(clipsLogged) != len(portMeters) {
+			clipsLogged = make([]uint64, len(portMeters))
+		}
+
+		for i, meter := range portMeters {
+			count, lastClip := meter.ClipEvents()
+
+			if count > clipsLogged[i] {
+				logClip(i, count-clipsLogged[i], lastClip)
+				clipsLogged[i] = count
+			}
+		}
+	})
+
+	processOnInterval("loudness", stats.shutdownChan, 100, func() {
+		if len(loudnessMeters) > 0 {
+			displayHandle.UpdateLoudness(loudnessLevels())
+		}
+	})
+
+	processOnInterval("stereo", stats.shutdownChan, 100, func() {
+		if len(stereoMeters) > 0 {
+			displayHandle.UpdateStereoLevels(stereoLevels())
+		}
+	})
+
+	// the fft runs here rather than on the process thread
+	processOnInterval("spectrum", stats.shutdownChan, 50, func() {
+		if spectrumAnalyzer != nil && spectrumAnalyzer.Port() >= 0 {
+			displayHandle.UpdateSpectrum(spectrumAnalyzer.Analyze())
+		}
+	})
+
 	processOnInterval("combined stats", stats.shutdownChan, 100, func() {
 		// buffer utilization
 		bufferSum := float64(0.0)
 		bufferCount := 0
 
 		for _, port := range ports {
-			if port.IsArmed() {
+			if port.IsAssigned() {
 				buffer := port.GetWriteBuffer()
 
 				bufferSum += float64(len(buffer)) / float64(cap(buffer))
@@ -194,14 +245,30 @@ func initStatistics(profile *model.Profile) chan bool {
 			}
 
 			// recording duration
-			duration := float64(stats.framesProcessed) / float64(profile.AudioServer.SampleRate) * (4096 / float64(profile.AudioServer.FramesPerPeriod))
-			displayHandle.SetDuration(duration)
+			stats.duration = float64(stats.framesProcessed.Load()) / float64(profile.AudioServer.SampleRate) * (4096 / float64(profile.AudioServer.FramesPerPeriod))
+			displayHandle.SetDuration(stats.duration)
 		}
 	}()
 
 	return stats.shutdownChan
 }
 
+func logClip(portNum int, count uint64, lastClip time.Time) {
+	source := fmt.Sprintf("input %d", portNum+1)
+
+	if channelName := ports[portNum].GetChannelName(); channelName != "" {
+		source += " (" + channelName + ")"
+	}
+
+	clipTime := lastClip.Format("15:04:05.000")
+
+	if count == 1 {
+		slog.Warn(fmt.Sprintf("Clip on %s at %s", source, clipTime))
+	} else {
+		slog.Warn(fmt.Sprintf("%d clips on %s, last at %s", count, source, clipTime))
+	}
+}
+
 func processOnInterval(name string, shutdownChan chan bool, milliseconds int, process func()) {
 	reaper.Register(name)
 
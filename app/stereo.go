@@ -0,0 +1,75 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"fox-audio/metering"
+	"fox-audio/model"
+)
+
+var (
+	stereoMeters []*metering.StereoMeter
+)
+
+// setupChannelGroups builds the meter bridge layout from the profile channels
+// and attaches a stereo meter to every 2 port channel. This must happen before
+// the JACK client is activated.
+func setupChannelGroups(profile *model.Profile) []model.UiChannelGroup {
+	groups := make([]model.UiChannelGroup, 0, len(profile.Channels))
+	stereoMeters = make([]*metering.StereoMeter, 0)
+
+	for _, channel := range profile.Channels {
+		group := model.UiChannelGroup{
+			Name:  channel.ChannelName,
+			Ports: make([]int, 0, len(channel.Ports)),
+		}
+
+		for _, portNum := range channel.Ports {
+			if portNum >= 1 && portNum <= len(ports) {
+				group.Ports = append(group.Ports, portNum-1)
+			}
+		}
+
+		if len(group.Ports) == 0 {
+			continue
+		}
+
+		groups = append(groups, group)
+
+		if len(group.Ports) == 2 {
+			stereoMeters = append(stereoMeters, metering.NewStereoMeter(channel.ChannelName, group.Ports, audioServer.GetSampleRate()))
+		}
+	}
+
+	return groups
+}
+
+func stereoLevels() []model.StereoLevel {
+	levels := make([]model.StereoLevel, len(stereoMeters))
+
+	for i, meter := range stereoMeters {
+		levels[i] = meter.Level()
+	}
+
+	return levels
+}
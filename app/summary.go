@@ -0,0 +1,70 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"fox-audio/model"
+	"fox-audio/util"
+)
+
+// showSummary waits for the disk writer to close all files and then hands the
+// final numbers to the UI. In TUI mode this blocks until the user dismisses it.
+func showSummary(profile *model.Profile) {
+	if diskWriterDone != nil {
+		<-diskWriterDone
+	}
+
+	displayHandle.ShowSummary(buildSummary(profile))
+}
+
+func buildSummary(profile *model.Profile) *model.SessionSummary {
+	outputFiles := getOutputFiles()
+
+	summary := &model.SessionSummary{
+		ProfileName:    profile.Name,
+		Take:           profile.Output.Take,
+		Directory:      profile.Output.Directory,
+		Duration:       stats.duration,
+		XrunCount:      int(stats.xrunCount.Load()),
+		DroppedSamples: stats.droppedSamples.Load(),
+		ErrorCount:     int(stats.errorCount.Load()),
+		DiskFree:       util.GetDiskSpace(profile.Output.Directory).Free,
+		Files:          make([]model.SessionSummaryFile, len(outputFiles)),
+		Loudness:       loudnessLevels(),
+	}
+
+	for i, outputFile := range outputFiles {
+		fileStats := outputFile.GetStats()
+
+		summary.Files[i] = model.SessionSummaryFile{
+			Name:           outputFile.ChannelName,
+			FileName:       outputFile.FileName,
+			Size:           fileStats.WrittenBytes,
+			PeakDbfs:       util.AmplitudeToDbFloor(fileStats.PeakAmplitude, -150),
+			ClippedSamples: fileStats.ClippedSamples,
+			Health:         outputFile.GetHealth(),
+		}
+	}
+
+	return summary
+}
@@ -0,0 +1,180 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package app
+
+import (
+	"log/slog"
+	"math"
+	"sync"
+
+	"fox-audio/audio"
+	"fox-audio/display"
+	"fox-audio/model"
+	"fox-audio/reaper"
+)
+
+const (
+	transportPollMs = 50
+
+	// a position jump bigger than this is a relocate rather than jitter
+	transportRelocateSeconds = 0.5
+)
+
+var (
+	jackTransport      *audio.Transport
+	jackTransportMode  string
+	jackTransportMutex sync.Mutex
+)
+
+// setupTransport follows or drives the JACK transport as set in the profile,
+// it must be called once the session is recording
+func setupTransport(profile *model.Profile) {
+	if profile.AudioServer.Transport == model.TransportModeNone {
+		return
+	}
+
+	transport, err := audioServer.OpenTransport()
+	if err != nil {
+		slog.Error(err.Error() + ", the JACK transport is ignored")
+		return
+	}
+
+	jackTransportMutex.Lock()
+	jackTransport = transport
+	jackTransportMode = profile.AudioServer.Transport
+	jackTransportMutex.Unlock()
+
+	reaper.Callback("close jack transport", closeTransport)
+
+	if jackTransportMode == model.TransportModeMaster {
+		slog.Info("Driving the JACK transport")
+		locateTransport()
+		driveTransport(true)
+		return
+	}
+
+	state, position := transport.Query()
+
+	if state != audio.TransportRolling {
+		slog.Info("Waiting for the JACK transport to roll")
+		transportRecord = false
+		displayHandle.SetTransportStatus(display.StatusPaused)
+	}
+
+	followTransport(state, position)
+}
+
+// closeTransport releases the transport client, polling stops once it is gone
+func closeTransport() {
+	jackTransportMutex.Lock()
+	defer jackTransportMutex.Unlock()
+
+	if jackTransport != nil {
+		jackTransport.Close()
+		jackTransport = nil
+	}
+}
+
+// driveTransport starts or stops the JACK transport along with the recording
+// when fox is the transport master
+func driveTransport(record bool) {
+	jackTransportMutex.Lock()
+	defer jackTransportMutex.Unlock()
+
+	if jackTransport == nil || jackTransportMode != model.TransportModeMaster {
+		return
+	}
+
+	if record {
+		jackTransport.Start()
+	} else {
+		jackTransport.Stop()
+	}
+}
+
+// locateTransport moves the JACK transport to the start when fox is the
+// transport master, so every take starts at zero
+func locateTransport() {
+	jackTransportMutex.Lock()
+	defer jackTransportMutex.Unlock()
+
+	if jackTransport == nil || jackTransportMode != model.TransportModeMaster {
+		return
+	}
+
+	if err := jackTransport.Locate(0); err != nil {
+		slog.Warn(err.Error())
+	}
+}
+
+// followTransport polls the JACK transport, recording while it rolls and
+// starting a new take when it is relocated. Only changes of the transport are
+// acted on, so the operator can still pause while it rolls.
+func followTransport(state audio.TransportState, position audio.TransportPosition) {
+	lastState := state
+	lastPosition := position
+	retry := false
+
+	processOnInterval("jack transport", stats.shutdownChan, transportPollMs, func() {
+		jackTransportMutex.Lock()
+		if jackTransport == nil {
+			jackTransportMutex.Unlock()
+			return
+		}
+		state, position := jackTransport.Query()
+		jackTransportMutex.Unlock()
+
+		sampleRate := float64(audioServer.GetSampleRate())
+
+		// the position is measured against the JACK clock rather than the
+		// poll interval, so a late poll isn't taken for a relocate
+		expected := float64(lastPosition.Frame)
+		if lastState == audio.TransportRolling && position.Usecs > lastPosition.Usecs {
+			expected += float64(position.Usecs-lastPosition.Usecs) / 1e6 * sampleRate
+		}
+
+		// nothing has been recorded to the take yet, so it is kept
+		if math.Abs(float64(position.Frame)-expected) > transportRelocateSeconds*sampleRate && stats.framesProcessed.Load() > 0 {
+			slog.Info("JACK transport relocated, starting a new take")
+
+			if err := handleAction(display.ActionNewTake, nil); err != nil {
+				slog.Warn("Unable to start a new take: " + err.Error())
+			}
+		}
+
+		rolling := state == audio.TransportRolling
+
+		// setting the transport fails while a new take is being started, so
+		// it is tried again on the next poll
+		if rolling != (lastState == audio.TransportRolling) || retry {
+			action := display.ActionPause
+			if rolling {
+				action = display.ActionRecord
+			}
+			retry = handleAction(action, nil) != nil
+		}
+
+		lastState = state
+		lastPosition = position
+	})
+}
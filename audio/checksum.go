@@ -0,0 +1,233 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package audio
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/go-audio/audio"
+)
+
+// WavChecksums holds the hashes calculated by reading a wav file back from disk
+type WavChecksums struct {
+	FileSha256  string
+	AudioSha256 string
+	AudioMd5    string
+
+	// contents of the BWF <md5 > chunk, if the file has one
+	EmbeddedMd5 string
+}
+
+// payloadHasher hashes the audio data chunk of a wav file as it is written,
+// encoding samples exactly the way the wav encoder does
+type payloadHasher struct {
+	bitDepth int
+	sha256   hash.Hash
+	md5      hash.Hash
+	writer   io.Writer
+	buffer   bytes.Buffer
+}
+
+func newPayloadHasher(bitDepth int, withMd5 bool) *payloadHasher {
+	hasher := &payloadHasher{
+		bitDepth: bitDepth,
+		sha256:   sha256.New(),
+	}
+
+	hasher.writer = hasher.sha256
+
+	if withMd5 {
+		hasher.md5 = md5.New()
+		hasher.writer = io.MultiWriter(hasher.sha256, hasher.md5)
+	}
+
+	return hasher
+}
+
+func (h *payloadHasher) write(buf *audio.IntBuffer) {
+	h.buffer.Reset()
+
+	for _, v := range buf.Data {
+		switch h.bitDepth {
+		case 8:
+			h.buffer.WriteByte(uint8(v))
+		case 16:
+			binary.Write(&h.buffer, binary.LittleEndian, int16(v))
+		case 24:
+			h.buffer.Write(audio.Int32toInt24LEBytes(int32(v)))
+		case 32:
+			binary.Write(&h.buffer, binary.LittleEndian, int32(v))
+		}
+	}
+
+	h.writer.Write(h.buffer.Bytes())
+}
+
+func (h *payloadHasher) sha256Sum() string {
+	return hex.EncodeToString(h.sha256.Sum(nil))
+}
+
+func (h *payloadHasher) md5Sum() []byte {
+	if h.md5 == nil {
+		return nil
+	}
+
+	return h.md5.Sum(nil)
+}
+
+// HashWavFile reads a wav file once, hashing the whole file along with the
+// contents of its data chunk
+func HashWavFile(filePath string) (*WavChecksums, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fileHash := sha256.New()
+	reader := io.TeeReader(f, fileHash)
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, errors.New("failed to read wav header: " + err.Error())
+	}
+
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errors.New("not a wav file")
+	}
+
+	checksums := &WavChecksums{}
+
+	// the RIFF size covers everything after the size field
+	riffEnd := int64(binary.LittleEndian.Uint32(header[4:8])) + 8
+	offset := int64(len(header))
+
+	for offset < riffEnd {
+		chunkHeader := make([]byte, 8)
+		if _, err := io.ReadFull(reader, chunkHeader); err != nil {
+			return nil, errors.New("failed to read wav chunk header: " + err.Error())
+		}
+
+		chunkId := string(chunkHeader[0:4])
+		chunkSize := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+
+		switch chunkId {
+		case "data":
+			audioSha256 := sha256.New()
+			audioMd5 := md5.New()
+
+			if _, err := io.CopyN(io.MultiWriter(audioSha256, audioMd5), reader, chunkSize); err != nil {
+				return nil, errors.New("failed to read wav data: " + err.Error())
+			}
+
+			checksums.AudioSha256 = hex.EncodeToString(audioSha256.Sum(nil))
+			checksums.AudioMd5 = hex.EncodeToString(audioMd5.Sum(nil))
+
+		case "md5 ":
+			// the size comes from the file, so it isn't trusted for the
+			// allocation
+			if chunkSize != md5.Size {
+				return nil, errors.New("invalid md5 chunk size: " + strconv.FormatInt(chunkSize, 10))
+			}
+
+			embedded := make([]byte, md5.Size)
+			if _, err := io.ReadFull(reader, embedded); err != nil {
+				return nil, errors.New("failed to read md5 chunk: " + err.Error())
+			}
+
+			checksums.EmbeddedMd5 = hex.EncodeToString(embedded)
+
+		default:
+			if _, err := io.CopyN(io.Discard, reader, chunkSize); err != nil {
+				return nil, errors.New("failed to read wav chunk '" + chunkId + "': " + err.Error())
+			}
+		}
+
+		offset += int64(len(chunkHeader)) + chunkSize
+
+		// chunks are padded to an even length, the encoder leaves out the
+		// pad byte when the data chunk is the last one in the file
+		if chunkSize%2 > 0 && offset < riffEnd {
+			if _, err := io.CopyN(io.Discard, reader, 1); err != nil {
+				return nil, errors.New("failed to read wav chunk padding: " + err.Error())
+			}
+
+			offset++
+		}
+	}
+
+	if offset != riffEnd {
+		return nil, errors.New("wav chunks don't match the RIFF size")
+	}
+
+	// make sure any trailing bytes end up in the file hash
+	io.Copy(io.Discard, reader)
+
+	checksums.FileSha256 = hex.EncodeToString(fileHash.Sum(nil))
+
+	return checksums, nil
+}
+
+// appendMd5Chunk adds a BWF <md5 > chunk containing the MD5 of the audio data
+// to the end of a closed wav file and fixes up the RIFF size
+func appendMd5Chunk(filePath string, sum []byte) error {
+	f, err := os.OpenFile(filePath, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	// an odd length data chunk needs its pad byte before another chunk
+	// can follow it
+	chunk := make([]byte, size%2, size%2+8+int64(len(sum)))
+	chunk = append(chunk, "md5 "...)
+	chunk = binary.LittleEndian.AppendUint32(chunk, uint32(len(sum)))
+	chunk = append(chunk, sum...)
+
+	if _, err := f.Write(chunk); err != nil {
+		return err
+	}
+
+	riffSize := make([]byte, 4)
+	binary.LittleEndian.PutUint32(riffSize, uint32(size+int64(len(chunk))-8))
+
+	if _, err := f.WriteAt(riffSize, 4); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
@@ -0,0 +1,191 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package audio
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+)
+
+func writeTestWav(t *testing.T, bitDepth int, frames int) (string, *payloadHasher) {
+	t.Helper()
+
+	filePath := filepath.Join(t.TempDir(), "test.wav")
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &audio.IntBuffer{
+		Data:   make([]int, frames),
+		Format: &audio.Format{NumChannels: 1, SampleRate: 48000},
+	}
+
+	for i := range buf.Data {
+		buf.Data[i] = i%100 - 50
+	}
+
+	hasher := newPayloadHasher(bitDepth, true)
+	hasher.write(buf)
+
+	encoder := wav.NewEncoder(f, 48000, bitDepth, 1, 1)
+	if err := encoder.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return filePath, hasher
+}
+
+func TestHashWavFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		bitDepth int
+		frames   int
+		md5      bool
+	}{
+		{"8 bit odd", 8, 1001, false},
+		{"8 bit odd with md5", 8, 1001, true},
+		{"8 bit even with md5", 8, 1000, true},
+		{"16 bit with md5", 16, 1001, true},
+		{"24 bit odd with md5", 24, 1001, true},
+		{"24 bit even with md5", 24, 1000, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filePath, hasher := writeTestWav(t, test.bitDepth, test.frames)
+
+			if test.md5 {
+				if err := appendMd5Chunk(filePath, hasher.md5Sum()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			checksums, err := HashWavFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if checksums.AudioSha256 != hasher.sha256Sum() {
+				t.Errorf("audio sha256 %s, expected %s", checksums.AudioSha256, hasher.sha256Sum())
+			}
+
+			expectedMd5 := ""
+			if test.md5 {
+				expectedMd5 = hex.EncodeToString(hasher.md5Sum())
+			}
+
+			if checksums.EmbeddedMd5 != expectedMd5 {
+				t.Errorf("embedded md5 %q, expected %q", checksums.EmbeddedMd5, expectedMd5)
+			}
+
+			if test.md5 && checksums.AudioMd5 != expectedMd5 {
+				t.Errorf("audio md5 %s, expected %s", checksums.AudioMd5, expectedMd5)
+			}
+
+			data, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if riffSize := binary.LittleEndian.Uint32(data[4:8]); int(riffSize) != len(data)-8 {
+				t.Errorf("riff size %d, expected %d", riffSize, len(data)-8)
+			}
+		})
+	}
+}
+
+func TestHashWavFileUnpaddedChunk(t *testing.T) {
+	filePath, hasher := writeTestWav(t, 8, 1001)
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an md5 chunk straight after the odd length data chunk, without the pad
+	// byte, as it used to be written
+	data = append(data, "md5 "...)
+	data = binary.LittleEndian.AppendUint32(data, 16)
+	data = append(data, hasher.md5Sum()...)
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)-8))
+
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := HashWavFile(filePath); err == nil {
+		t.Error("expected a misaligned md5 chunk to fail")
+	}
+}
+
+func TestHashWavFileMd5ChunkSize(t *testing.T) {
+	tests := []struct {
+		name string
+		size uint32
+	}{
+		{"empty", 0},
+		{"short", 15},
+		{"long", 17},
+		{"huge", 0xffffffff},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filePath, hasher := writeTestWav(t, 8, 1000)
+
+			data, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data = append(data, "md5 "...)
+			data = binary.LittleEndian.AppendUint32(data, test.size)
+			data = append(data, hasher.md5Sum()...)
+			binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)-8))
+
+			if err := os.WriteFile(filePath, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := HashWavFile(filePath); err == nil {
+				t.Errorf("expected an md5 chunk of %d bytes to fail", test.size)
+			}
+		})
+	}
+}
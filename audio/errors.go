@@ -0,0 +1,81 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package audio
+
+import (
+	"errors"
+	"syscall"
+)
+
+const (
+	OpCreate   = "create"
+	OpWrite    = "write"
+	OpClose    = "close"
+	OpChecksum = "checksum"
+	OpVerify   = "verify"
+)
+
+var (
+	ErrFileClosed       = errors.New("output file is closed")
+	ErrChecksumMismatch = errors.New("audio data on disk does not match what was written")
+	ErrMd5Missing       = errors.New("embedded md5 chunk is missing or does not match the audio data")
+)
+
+// OutputFileError describes a failed operation on a single output file
+type OutputFileError struct {
+	Op          string
+	ChannelName string
+	FileName    string
+	Err         error
+}
+
+func newOutputFileError(op string, outputFile *OutputFile, err error) *OutputFileError {
+	return &OutputFileError{
+		Op:          op,
+		ChannelName: outputFile.ChannelName,
+		FileName:    outputFile.FileName,
+		Err:         err,
+	}
+}
+
+func (e *OutputFileError) Error() string {
+	return "failed to " + e.Op + " output file " + e.FileName + ": " + e.Err.Error()
+}
+
+func (e *OutputFileError) Unwrap() error {
+	return e.Err
+}
+
+// Transient reports whether the underlying error is one that may clear up
+// on its own, such as an interrupted syscall or a busy/flaky device
+func (e *OutputFileError) Transient() bool {
+	return isTransient(e.Err)
+}
+
+func isTransient(err error) bool {
+	return errors.Is(err, syscall.EINTR) ||
+		errors.Is(err, syscall.EAGAIN) ||
+		errors.Is(err, syscall.EBUSY) ||
+		errors.Is(err, syscall.ETIMEDOUT) ||
+		errors.Is(err, syscall.EIO)
+}
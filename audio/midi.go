@@ -0,0 +1,99 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package audio
+
+import (
+	"fmt"
+	"log/slog"
+	"unsafe"
+
+	"github.com/hairlesshobo/go-jack"
+)
+
+const (
+	// events read in a single process cycle, the rest are dropped
+	midiEventCapacity = 64
+
+	// bytes kept of each event, longer messages are cut short
+	midiEventSize = 32
+)
+
+// MidiEvent is a MIDI message read during a process cycle
+type MidiEvent struct {
+	Time uint32
+	Size int
+	Data [midiEventSize]byte
+}
+
+// MidiPort is a JACK MIDI input that control messages are read from
+type MidiPort struct {
+	myName   string
+	jackPort *jack.Port
+	events   []MidiEvent
+}
+
+// RegisterMidiInput registers a MIDI input port, it must be called before the
+// client is activated
+func (server *JackServer) RegisterMidiInput(name string) *MidiPort {
+	jackPort := server.jackClient.PortRegister(name, jack.DEFAULT_MIDI_TYPE, jack.PortIsInput, 0)
+	if jackPort == nil {
+		slog.Error("Failed to register MIDI port " + name)
+		return nil
+	}
+
+	slog.Debug("Registered MIDI port " + name)
+
+	return &MidiPort{
+		myName:   name,
+		jackPort: jackPort,
+		events:   make([]MidiEvent, midiEventCapacity),
+	}
+}
+
+// ConnectMidiInput connects the MIDI sources to the port, sources that are
+// missing are logged and skipped
+func (server *JackServer) ConnectMidiInput(port *MidiPort, sources []string) {
+	portName := fmt.Sprintf("%s:%s", server.config.JackClientName, port.myName)
+
+	for _, source := range sources {
+		if code := server.jackClient.Connect(source, portName); code != 0 {
+			slog.Warn(fmt.Sprintf("Failed to connect MIDI source %s: %s", source, jack.StrError(code)))
+			continue
+		}
+
+		slog.Info(fmt.Sprintf("Connected MIDI source %s to %s", source, portName))
+	}
+}
+
+// GetEvents returns the MIDI events of the current process cycle. The events
+// are reused by the next cycle, so they must not be kept.
+func (port *MidiPort) GetEvents(nframes uint32) []MidiEvent {
+	// go-jack only hands out port buffers as samples, the MIDI buffer starts
+	// at the same address
+	buffer := port.jackPort.GetBuffer(nframes)
+	if len(buffer) == 0 {
+		return port.events[:0]
+	}
+
+	return port.events[:readMidiEvents(unsafe.Pointer(&buffer[0]), port.events)]
+}
@@ -0,0 +1,79 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package audio
+
+/*
+#include <string.h>
+#include <jack/midiport.h>
+
+// copies one event into memory owned by the caller, returning the number of
+// bytes copied or -1 when there is no such event
+static int fox_midi_event_read(void *buffer, uint32_t index, uint32_t *time, unsigned char *data, size_t capacity) {
+	jack_midi_event_t event;
+
+	if (jack_midi_event_get(&event, buffer, index) != 0) {
+		return -1;
+	}
+
+	if (event.size < capacity) {
+		capacity = event.size;
+	}
+
+	*time = event.time;
+	memcpy(data, event.buffer, capacity);
+
+	return (int)capacity;
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// readMidiEvents copies the events of a MIDI port buffer into events. It
+// runs in the process callback, so nothing is allocated; events past the end
+// of the slice are dropped.
+func readMidiEvents(buffer unsafe.Pointer, events []MidiEvent) int {
+	count := int(C.jack_midi_get_event_count(buffer))
+	if count > len(events) {
+		count = len(events)
+	}
+
+	read := 0
+
+	for i := 0; i < count; i++ {
+		event := &events[read]
+
+		size := C.fox_midi_event_read(buffer, C.uint32_t(i), (*C.uint32_t)(unsafe.Pointer(&event.Time)),
+			(*C.uchar)(unsafe.Pointer(&event.Data[0])), C.size_t(len(event.Data)))
+		if size < 0 {
+			continue
+		}
+
+		event.Size = int(size)
+		read++
+	}
+
+	return read
+}
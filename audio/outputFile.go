@@ -23,9 +23,15 @@
 package audio
 
 import (
-	"errors"
+	"encoding/hex"
+	"fmt"
 	"log/slog"
 	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fox-audio/model"
 
 	"github.com/go-audio/audio"
 	"github.com/go-audio/wav"
@@ -43,6 +49,32 @@ type OutputFile struct {
 	BitDepth     int
 	SampleRate   int
 	FileOpen     bool
+
+	// written by the disk writer and read by the statistics and UI
+	health     atomic.Int32
+	lastError  atomic.Pointer[OutputFileError]
+	retryCount atomic.Int32
+
+	// counters kept by the disk writer, copied out by GetStats
+	stats      OutputFileStats
+	statsMutex sync.Mutex
+
+	// populated when the file is closed, if checksums are enabled
+	checksums atomic.Pointer[WavChecksums]
+
+	hasher *payloadHasher
+
+	// set while the operator has disarmed the channel, the file keeps
+	// receiving silence so it stays aligned with the rest of the take
+	standby atomic.Bool
+}
+
+// OutputFileStats are the counters of a file as of its last write
+type OutputFileStats struct {
+	FramesWritten  uint64
+	PeakAmplitude  float32
+	ClippedSamples uint64
+	WrittenBytes   uint64
 }
 
 func (of *OutputFile) GetWriteBuffers() []chan float32 {
@@ -57,25 +89,214 @@ func (of *OutputFile) GetWriteBuffers() []chan float32 {
 	return buffers
 }
 
-func (of *OutputFile) Close() {
-	if of.FileOpen {
-		slog.Info("Closing file " + of.FileName)
-		if of.Encoder != nil {
-			of.Encoder.Close()
+// IsActive returns true if the output file is enabled and has not failed
+func (of *OutputFile) IsActive() bool {
+	return of.Enabled && of.GetHealth() != model.FileHealthFailed
+}
+
+func (of *OutputFile) GetHealth() model.FileHealth {
+	return model.FileHealth(of.health.Load())
+}
+
+func (of *OutputFile) setHealth(health model.FileHealth) {
+	of.health.Store(int32(health))
+}
+
+// GetLastError returns the error that failed the file, if any
+func (of *OutputFile) GetLastError() error {
+	if err := of.lastError.Load(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (of *OutputFile) GetRetryCount() int {
+	return int(of.retryCount.Load())
+}
+
+// GetStats returns a copy of the counters, which the disk writer keeps
+// updating while the file is open
+func (of *OutputFile) GetStats() OutputFileStats {
+	of.statsMutex.Lock()
+	defer of.statsMutex.Unlock()
+
+	return of.stats
+}
+
+// GetChecksums returns the checksums read back from the closed file, or nil
+func (of *OutputFile) GetChecksums() *WavChecksums {
+	return of.checksums.Load()
+}
+
+// RecordWrite adds a successful write to the counters, it must be called by
+// the disk writer after Write
+func (of *OutputFile) RecordWrite(frames uint64, peak float32, clipped uint64) {
+	of.statsMutex.Lock()
+	defer of.statsMutex.Unlock()
+
+	of.stats.FramesWritten += frames
+	of.stats.PeakAmplitude = max(of.stats.PeakAmplitude, peak)
+	of.stats.ClippedSamples += clipped
+
+	if of.Encoder != nil {
+		of.stats.WrittenBytes = uint64(of.Encoder.WrittenBytes)
+	}
+}
+
+// Disarm detaches the input ports from this output file so that the process
+// callback stops filling their write buffers
+func (of *OutputFile) Disarm() {
+	for _, port := range of.InputPorts {
+		if port != nil {
+			port.outputFile.CompareAndSwap(of, nil)
+		}
+	}
+}
+
+// SetStandby disarms or re-arms the channel without detaching its ports
+func (of *OutputFile) SetStandby(standby bool) {
+	of.standby.Store(standby)
+}
+
+func (of *OutputFile) InStandby() bool {
+	return of.standby.Load()
+}
+
+func (of *OutputFile) Close() error {
+	if !of.FileOpen {
+		return nil
+	}
+
+	slog.Info("Closing file " + of.FileName)
+	of.FileOpen = false
+
+	if of.Encoder != nil {
+		if err := of.Encoder.Close(); err != nil {
+			of.FileHandle.Close()
+			return of.fail(OpClose, err)
 		}
+	}
 
-		if of.FileHandle != nil {
+	if of.FileHandle != nil {
+		if err := of.FileHandle.Sync(); err != nil {
 			of.FileHandle.Close()
+			return of.fail(OpClose, err)
+		}
+
+		if err := of.FileHandle.Close(); err != nil {
+			return of.fail(OpClose, err)
 		}
+	}
 
-		of.FileOpen = false
+	// a failed file can't match what was sent to it
+	if of.GetHealth() == model.FileHealthFailed {
+		return nil
 	}
+
+	return of.embedMd5()
 }
 
 func (of *OutputFile) Write(buf *audio.IntBuffer) error {
 	if !of.FileOpen {
-		return errors.New("output file is already closed, " + of.FileName)
+		return newOutputFileError(OpWrite, of, ErrFileClosed)
+	}
+
+	if err := of.Encoder.Write(buf); err != nil {
+		return of.fail(OpWrite, err)
+	}
+
+	if of.hasher != nil {
+		of.hasher.write(buf)
+	}
+
+	// any retries that happened during this write succeeded
+	if of.health.CompareAndSwap(int32(model.FileHealthRetrying), int32(model.FileHealthOK)) {
+		slog.Info(fmt.Sprintf("Output file %s recovered after %d retries", of.FileName, of.GetRetryCount()))
 	}
 
-	return of.Encoder.Write(buf)
+	return nil
+}
+
+//
+// private functions
+//
+
+func (of *OutputFile) open(output *model.ProfileOutput) error {
+	var err error
+
+	retries := output.WriteRetries
+	retryDelay := time.Duration(output.WriteRetryDelayMs) * time.Millisecond
+
+	of.FileHandle, err = os.Create(of.FilePath)
+	if err != nil {
+		return of.fail(OpCreate, err)
+	}
+
+	if output.Checksum {
+		of.hasher = newPayloadHasher(of.BitDepth, output.ChecksumMd5)
+	}
+
+	writer := newRetryWriter(of.FileHandle, retries, retryDelay, func(attempt int, err error) {
+		of.setHealth(model.FileHealthRetrying)
+		of.retryCount.Add(1)
+		slog.Warn(fmt.Sprintf("Retrying write to %s (attempt %d/%d): %s", of.FileName, attempt, retries, err.Error()))
+	})
+
+	of.Encoder = wav.NewEncoder(writer, of.SampleRate, of.BitDepth, of.ChannelCount, 1)
+	of.FileOpen = true
+
+	return nil
+}
+
+// Verify reads the closed file back to make sure the audio on disk is what we
+// wrote. This reads the whole file, so it is left to the caller to run it
+// where it doesn't hold up recording.
+func (of *OutputFile) Verify() error {
+	if of.hasher == nil || of.FileOpen || of.GetHealth() == model.FileHealthFailed {
+		return nil
+	}
+
+	md5Sum := of.hasher.md5Sum()
+
+	checksums, err := HashWavFile(of.FilePath)
+	if err != nil {
+		return of.fail(OpChecksum, err)
+	}
+
+	of.checksums.Store(checksums)
+
+	if checksums.AudioSha256 != of.hasher.sha256Sum() {
+		return of.fail(OpVerify, ErrChecksumMismatch)
+	}
+
+	if md5Sum != nil && checksums.EmbeddedMd5 != hex.EncodeToString(md5Sum) {
+		return of.fail(OpVerify, ErrMd5Missing)
+	}
+
+	return nil
+}
+
+// embedMd5 adds the BWF md5 chunk to the closed file, if requested
+func (of *OutputFile) embedMd5() error {
+	if of.hasher == nil {
+		return nil
+	}
+
+	if md5Sum := of.hasher.md5Sum(); md5Sum != nil {
+		if err := appendMd5Chunk(of.FilePath, md5Sum); err != nil {
+			return of.fail(OpChecksum, err)
+		}
+	}
+
+	return nil
+}
+
+func (of *OutputFile) fail(op string, err error) *OutputFileError {
+	ofErr := newOutputFileError(op, of, err)
+
+	of.lastError.Store(ofErr)
+	of.setHealth(model.FileHealthFailed)
+
+	return ofErr
 }
@@ -23,6 +23,8 @@
 package audio
 
 import (
+	"sync/atomic"
+
 	"github.com/hairlesshobo/go-jack"
 )
 
@@ -36,17 +38,21 @@ const (
 type Port struct {
 	portDirection PortDirection
 	myName        string
+	number        int
 	connected     bool
 	jackName      string
 	jackPort      *jack.Port
 	buffer        chan float32
-	outputFile    *OutputFile
+
+	// read by the process callback every cycle
+	outputFile atomic.Pointer[OutputFile]
 }
 
-func newPort(direction PortDirection, myName string, jackName string) *Port {
+func newPort(direction PortDirection, number int, myName string, jackName string) *Port {
 	return &Port{
 		portDirection: direction,
 		myName:        myName,
+		number:        number,
 		jackName:      jackName,
 		connected:     false,
 	}
@@ -64,6 +70,16 @@ func (port *Port) GetJackPort() *jack.Port {
 	return port.jackPort
 }
 
+// GetSourceName returns the name of the JACK port this port is fed from
+func (port *Port) GetSourceName() string {
+	return port.jackName
+}
+
+// GetNumber returns the 1 based number of the port
+func (port *Port) GetNumber() int {
+	return port.number
+}
+
 func (port *Port) GetJackBuffer(nframes uint32) []jack.AudioSample {
 	return port.jackPort.GetBuffer(nframes)
 }
@@ -82,6 +98,24 @@ func (port *Port) GetWriteBuffer() chan float32 {
 	return port.buffer
 }
 
+// IsAssigned returns true if the port feeds an output file, even one that the
+// operator has disarmed
+func (port *Port) IsAssigned() bool {
+	return port.outputFile.Load() != nil
+}
+
 func (port *Port) IsArmed() bool {
-	return port.outputFile != nil
+	outputFile := port.outputFile.Load()
+
+	return outputFile != nil && !outputFile.InStandby()
+}
+
+// GetChannelName returns the name of the profile channel this port is armed for
+func (port *Port) GetChannelName() string {
+	outputFile := port.outputFile.Load()
+	if outputFile == nil {
+		return ""
+	}
+
+	return outputFile.ChannelName
 }
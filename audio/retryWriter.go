@@ -0,0 +1,89 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package audio
+
+import (
+	"os"
+	"time"
+)
+
+// retryWriter sits between the wav encoder and the file handle so that
+// transient errors are retried at the byte level. Retrying at the encoder
+// level isn't safe because the encoder keeps its unflushed buffer around
+// after a failed write, which would duplicate audio in the file.
+type retryWriter struct {
+	file    *os.File
+	retries int
+	delay   time.Duration
+	onRetry func(attempt int, err error)
+}
+
+func newRetryWriter(file *os.File, retries int, delay time.Duration, onRetry func(int, error)) *retryWriter {
+	return &retryWriter{
+		file:    file,
+		retries: retries,
+		delay:   delay,
+		onRetry: onRetry,
+	}
+}
+
+func (w *retryWriter) Write(p []byte) (int, error) {
+	written := 0
+	delay := w.delay
+
+	for attempt := 1; ; attempt++ {
+		n, err := w.file.Write(p[written:])
+		written += n
+
+		if err == nil {
+			return written, nil
+		}
+
+		if attempt > w.retries || !isTransient(err) {
+			return written, err
+		}
+
+		w.onRetry(attempt, err)
+
+		// exponential backoff
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (w *retryWriter) Seek(offset int64, whence int) (int64, error) {
+	delay := w.delay
+
+	for attempt := 1; ; attempt++ {
+		pos, err := w.file.Seek(offset, whence)
+
+		if err == nil || attempt > w.retries || !isTransient(err) {
+			return pos, err
+		}
+
+		w.onRetry(attempt, err)
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -27,7 +27,6 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"os/exec"
 	"path"
 	"strings"
@@ -38,7 +37,6 @@ import (
 	"fox-audio/reaper"
 	"fox-audio/util"
 
-	"github.com/go-audio/wav"
 	"github.com/hairlesshobo/go-jack"
 )
 
@@ -262,15 +260,17 @@ func (server *JackServer) Connect(processCallback jack.ProcessCallback, xrunCall
 }
 
 func (server *JackServer) PrepareOutputFiles() {
-	for _, channel := range server.profile.Channels {
-		portNumbers := make([]string, len(channel.Ports))
+	// a new take replaces the files of the previous one
+	server.outputFiles = make([]*OutputFile, 0, len(server.profile.Channels))
 
-		for i, channel := range channel.Ports {
-			portNumbers[i] = fmt.Sprintf("%02d", channel)
+	for _, channel := range server.profile.Channels {
+		fileName, err := util.FileName(server.profile, &channel)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to build file name for channel '%s': %s", channel.ChannelName, err.Error()))
+			reaper.Reap()
+			return
 		}
 
-		fileName := fmt.Sprintf("%s_channel%s_%s.wav", server.profile.Output.Take, strings.Join(portNumbers, "-"), channel.ChannelName)
-
 		outputFile := &OutputFile{
 			ChannelName:  channel.ChannelName,
 			Enabled:      !channel.Disabled,
@@ -284,37 +284,48 @@ func (server *JackServer) PrepareOutputFiles() {
 		}
 
 		// if the channel isn't enabled, we skip creating output files or buffers
-		if !channel.Disabled {
+		if channel.Disabled {
+			outputFile.setHealth(model.FileHealthDisabled)
+		} else {
 			slog.Info("Creating output file " + outputFile.FilePath)
 
-			var err error
-			outputFile.FileHandle, err = os.Create(outputFile.FilePath)
-			if err != nil {
-				slog.Error("error creating %s: %s", outputFile.FilePath, err)
-			}
+			if err := outputFile.open(&server.profile.Output); err != nil {
+				slog.Error(err.Error())
+
+				if server.profile.Output.ErrorPolicy == model.ErrorPolicyStopAll {
+					reaper.Reap()
+					return
+				}
 
-			outputFile.Encoder = wav.NewEncoder(outputFile.FileHandle, outputFile.SampleRate, outputFile.BitDepth, len(channel.Ports), 1)
+				// leave the ports unassigned so they are never armed
+				slog.Warn(fmt.Sprintf("Continuing without channel '%s' per profile error policy", outputFile.ChannelName))
+				server.outputFiles = append(server.outputFiles, outputFile)
+				continue
+			}
 
 			for channelNum, channelPort := range channel.Ports {
 				jackPort := server.findJackPort(fmt.Sprintf("%d", channelPort), In)
 
 				if jackPort != nil {
 					// this should make sure a port can only be assigned once
-					if jackPort.outputFile != nil && jackPort.outputFile != outputFile {
-						slog.Error(fmt.Sprintf("Error assigning output port to file '%s' because input port %d is already assigned to '%s'", channel.ChannelName, channelPort, jackPort.outputFile.ChannelName))
+					if assigned := jackPort.outputFile.Load(); assigned != nil && assigned != outputFile {
+						slog.Error(fmt.Sprintf("Error assigning output port to file '%s' because input port %d is already assigned to '%s'", channel.ChannelName, channelPort, assigned.ChannelName))
 						reaper.Reap()
 						return
 					}
 
-					jackPort.outputFile = outputFile
+					jackPort.outputFile.Store(outputFile)
 					outputFile.InputPorts[channelNum] = jackPort
 
-					success := jackPort.AllocateBuffer(int(float64(server.profile.AudioServer.SampleRate) * server.profile.Output.BufferSizeSeconds))
+					// buffers are kept from the previous take
+					if cap(jackPort.buffer) == 0 {
+						success := jackPort.AllocateBuffer(int(float64(server.profile.AudioServer.SampleRate) * server.profile.Output.BufferSizeSeconds))
 
-					if !success {
-						slog.Error("Failed to allocate buffer for port " + jackPort.jackName)
-						reaper.Reap()
-						return
+						if !success {
+							slog.Error("Failed to allocate buffer for port " + jackPort.jackName)
+							reaper.Reap()
+							return
+						}
 					}
 				} else {
 					slog.Error(fmt.Sprintf("Input port '%d' specified by '%s' channel does not exist", channelPort, outputFile.ChannelName))
@@ -322,8 +333,6 @@ func (server *JackServer) PrepareOutputFiles() {
 					return
 				}
 			}
-
-			outputFile.FileOpen = true
 		}
 
 		server.outputFiles = append(server.outputFiles, outputFile)
@@ -399,9 +408,13 @@ func (server *JackServer) ConnectPorts(connectInput bool, connectOutput bool) {
 	slog.Info("Audio ports connected")
 }
 
+// CloseOutputFiles closes every file that is still open, including the ones
+// that failed and are no longer written to
 func (server *JackServer) CloseOutputFiles() {
 	for _, outputFile := range server.outputFiles {
-		outputFile.Close()
+		if err := outputFile.Close(); err != nil {
+			slog.Error(err.Error())
+		}
 	}
 }
 
@@ -571,7 +584,7 @@ func (server *JackServer) populatePorts() {
 	// get input ports
 	inputPorts := server.jackClient.GetPorts(server.config.HardwarePortConnectionPrefix+"*", "", jack.PortIsOutput) // | jack.PortIsPhysical)
 	for i, port := range inputPorts {
-		server.ports = append(server.ports, newPort(In, fmt.Sprintf("in_%d", i+1), port))
+		server.ports = append(server.ports, newPort(In, i+1, fmt.Sprintf("in_%d", i+1), port))
 	}
 
 	// get output ports
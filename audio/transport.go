@@ -0,0 +1,124 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package audio
+
+/*
+#cgo linux LDFLAGS: -ljack
+#cgo darwin LDFLAGS: -ljack
+
+#include <stdlib.h>
+#include <jack/jack.h>
+#include <jack/transport.h>
+
+// jack_client_open is variadic, which cgo can't call
+static jack_client_t *fox_transport_open(const char *name) {
+	jack_status_t status;
+	return jack_client_open(name, JackNoStartServer, &status);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+type TransportState int
+
+const (
+	TransportStopped TransportState = iota
+	TransportRolling
+	TransportStarting
+)
+
+// TransportPosition is where the transport was when it was queried. Usecs is
+// the JACK clock at the start of that process cycle, so positions of two
+// queries can be compared without depending on when the queries ran.
+type TransportPosition struct {
+	Frame uint32
+	Usecs uint64
+}
+
+// Transport queries and controls the JACK transport. go-jack doesn't cover the
+// transport API, so it uses a client of its own that has no ports.
+type Transport struct {
+	client *C.jack_client_t
+}
+
+// OpenTransport connects the transport client to the running server
+func (server *JackServer) OpenTransport() (*Transport, error) {
+	name := C.CString(server.config.JackClientName + "_transport")
+	defer C.free(unsafe.Pointer(name))
+
+	client := C.fox_transport_open(name)
+	if client == nil {
+		return nil, errors.New("failed to open the JACK transport client")
+	}
+
+	if C.jack_activate(client) != 0 {
+		C.jack_client_close(client)
+		return nil, errors.New("failed to activate the JACK transport client")
+	}
+
+	return &Transport{client: client}, nil
+}
+
+// Query returns the transport state and position
+func (transport *Transport) Query() (TransportState, TransportPosition) {
+	var position C.jack_position_t
+
+	state := TransportStarting
+
+	// jack2 adds a net starting state, which is treated as starting
+	switch C.jack_transport_query(transport.client, &position) {
+	case C.JackTransportStopped:
+		state = TransportStopped
+	case C.JackTransportRolling, C.JackTransportLooping:
+		state = TransportRolling
+	}
+
+	return state, TransportPosition{
+		Frame: uint32(position.frame),
+		Usecs: uint64(position.usecs),
+	}
+}
+
+func (transport *Transport) Start() {
+	C.jack_transport_start(transport.client)
+}
+
+func (transport *Transport) Stop() {
+	C.jack_transport_stop(transport.client)
+}
+
+func (transport *Transport) Locate(frame uint32) error {
+	if C.jack_transport_locate(transport.client, C.jack_nframes_t(frame)) != 0 {
+		return errors.New("failed to locate the JACK transport")
+	}
+
+	return nil
+}
+
+func (transport *Transport) Close() {
+	C.jack_client_close(transport.client)
+}
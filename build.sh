@@ -11,5 +11,8 @@ cp -v default.profile.sample dist/default.profile
 echo "Copying default config..."
 cp -v fox.config.sample dist/fox.config
 
+echo "Copying sample service unit..."
+cp -v fox.service.sample dist/fox.service
+
 echo "Building application..."
 go build -o dist/fox fox.go
@@ -0,0 +1,55 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+// Action is a request from the operator that has to be carried out outside of
+// the display, such as selecting the port fed to the spectrum analyzer
+type Action int
+
+const (
+	// select the analyzed port, args[0] is the 0 based port index or -1 to stop
+	ActionSpectrumPort Action = iota
+
+	// resume writing to the output files
+	ActionRecord
+
+	// stop writing to the output files without closing them
+	ActionPause
+
+	// finish the session
+	ActionStop
+
+	// close the current take and start recording the next one
+	ActionNewTake
+
+	// arm or disarm a channel, args[0] is a 1 based input port number or the
+	// channel name
+	ActionArm
+	ActionDisarm
+
+	// mark the current position in the take manifest, args are joined to form
+	// the optional marker name
+	ActionMarker
+)
+
+type ActionHandler func(action Action, args []string) error
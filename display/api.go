@@ -0,0 +1,507 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"context"
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"fox-audio/model"
+	"fox-audio/util"
+)
+
+const (
+	// oldest log entries are dropped past this
+	apiLogMaxEntries = 1000
+
+	// below trace, so every entry is included
+	apiLogAllLevels = slog.Level(-100)
+
+	apiShutdownTimeout = 2 * time.Second
+)
+
+// the web ui, served from the root of the control API so it works without
+// internet access
+//
+//go:embed web
+var webFiles embed.FS
+
+// commands accepted by the control API, channel commands take the input number
+// or channel name as the last path element
+var apiCommands = []struct {
+	name    string
+	action  Action
+	channel bool
+}{
+	{"record", ActionRecord, false},
+	{"pause", ActionPause, false},
+	{"stop", ActionStop, false},
+	{"take", ActionNewTake, false},
+	{"arm", ActionArm, true},
+	{"disarm", ActionDisarm, true},
+	{"marker", ActionMarker, false},
+}
+
+//
+// types
+//
+
+// ApiUI serves the session state over HTTP and pushes it to websocket clients.
+// It runs alongside another display, see MultiUI.
+type ApiUI struct {
+	// keeps the state exactly the way the JSON output reports it
+	*JsonUI
+
+	options  *model.ApiOptions
+	listener net.Listener
+	server   *http.Server
+	done     chan bool
+
+	actionHandler ActionHandler
+
+	// latest encoded message of each type, by message type
+	snapshot      map[string][]byte
+	snapshotMutex sync.RWMutex
+
+	logEntries []logEntry
+	logMutex   sync.Mutex
+
+	clients     map[*websocketConn]bool
+	clientMutex sync.Mutex
+}
+
+//
+// constructor
+//
+
+func NewApiUI(options *model.ApiOptions) *ApiUI {
+	apiUi := &ApiUI{
+		JsonUI: NewJsonUI(nil),
+
+		options: options,
+		done:    make(chan bool),
+
+		snapshot:   make(map[string][]byte),
+		logEntries: make([]logEntry, 0),
+		clients:    make(map[*websocketConn]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", apiUi.serveSnapshot("status"))
+	mux.HandleFunc("GET /api/levels", apiUi.serveSnapshot("levels"))
+	mux.HandleFunc("GET /api/files", apiUi.serveSnapshot("files"))
+	mux.HandleFunc("GET /api/loudness", apiUi.serveSnapshot("loudness"))
+	mux.HandleFunc("GET /api/logs", apiUi.serveLogs)
+	mux.HandleFunc("GET /api/ws", apiUi.serveWebsocket)
+
+	webRoot, _ := fs.Sub(webFiles, "web")
+	mux.Handle("GET /", http.FileServerFS(webRoot))
+
+	for _, command := range apiCommands {
+		pattern := "POST /api/" + command.name
+		if command.channel {
+			pattern += "/{channel}"
+		}
+
+		mux.HandleFunc(pattern, apiUi.serveCommand(command.name, command.action, command.channel))
+	}
+
+	apiUi.server = &http.Server{
+		Handler:           apiUi.authorize(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return apiUi
+}
+
+// Listen opens the listen address so a port that is already taken is reported
+// before the session starts
+func (a *ApiUI) Listen() error {
+	listener, err := net.Listen("tcp", a.options.Listen)
+	if err != nil {
+		return errors.New("failed to listen on " + a.options.Listen + ": " + err.Error())
+	}
+
+	a.listener = listener
+
+	return nil
+}
+
+func (a *ApiUI) Initalize() {
+	// nothing to do here
+}
+
+func (a *ApiUI) Start() {
+	a.pushSnapshot()
+
+	go a.serve()
+	go a.pushLoop()
+}
+
+func (a *ApiUI) Shutdown() {
+	slog.Debug("Shutting down control API")
+	close(a.done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+	defer cancel()
+
+	a.server.Shutdown(ctx)
+
+	// websockets were taken over from the server, so they are closed here
+	a.clientMutex.Lock()
+	for client := range a.clients {
+		client.writeFrame(websocketOpClose, nil)
+		client.close()
+	}
+	a.clientMutex.Unlock()
+}
+
+func (a *ApiUI) IsShutdown() bool {
+	select {
+	case <-a.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (a *ApiUI) WaitForShutdown() {
+	<-a.done
+}
+
+func (a *ApiUI) SetActionHandler(handler ActionHandler) {
+	a.actionHandler = handler
+}
+
+func (a *ApiUI) WriteLevelLog(level slog.Level, message string) {
+	entry := logEntry{time: time.Now(), level: level, message: message}
+
+	// broadcast while locked so a client that is connecting gets each entry
+	// either with the buffered log or as it is pushed, never both
+	a.logMutex.Lock()
+	defer a.logMutex.Unlock()
+
+	a.logEntries = append(a.logEntries, entry)
+	if len(a.logEntries) > apiLogMaxEntries {
+		a.logEntries = a.logEntries[len(a.logEntries)-apiLogMaxEntries:]
+	}
+
+	log := jsonLogEntry(entry)
+	a.broadcast(&log)
+}
+
+func (a *ApiUI) ShowSummary(summary *model.SessionSummary) {
+	a.broadcast(jsonSummary(summary))
+}
+
+func (a *ApiUI) HandlePanic() {
+	// nothing to do here
+}
+
+//
+// private functions
+//
+
+func (a *ApiUI) serve() {
+	slog.Info("Control API and web UI listening on http://" + a.listener.Addr().String())
+
+	if err := a.server.Serve(a.listener); err != nil && err != http.ErrServerClosed {
+		slog.Error("Control API stopped: " + err.Error())
+	}
+}
+
+func (a *ApiUI) pushLoop() {
+	ticker := time.NewTicker(time.Duration(a.options.PushIntervalMs) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			a.pushSnapshot()
+		}
+	}
+}
+
+// pushSnapshot encodes the current state for the REST endpoints and sends it
+// to every websocket client
+func (a *ApiUI) pushSnapshot() {
+	snapshot := map[string][]byte{
+		"status":   a.encode(a.getStatus()),
+		"levels":   a.encode(a.getLevels()),
+		"files":    a.encode(a.getOutputFiles()),
+		"loudness": a.encode(a.getLoudness()),
+	}
+
+	a.snapshotMutex.Lock()
+	a.snapshot = snapshot
+	a.snapshotMutex.Unlock()
+
+	a.sendSnapshot(nil)
+}
+
+// sendSnapshot sends the latest state to a single client, or to all of them
+// when client is nil. Loudness is only pushed when meters are configured, the
+// same as the JSON output.
+func (a *ApiUI) sendSnapshot(client *websocketConn) {
+	a.snapshotMutex.RLock()
+	defer a.snapshotMutex.RUnlock()
+
+	messageTypes := []string{"status", "levels", "files"}
+	if len(a.loudness) > 0 {
+		messageTypes = append(messageTypes, "loudness")
+	}
+
+	for _, messageType := range messageTypes {
+		if client != nil {
+			a.sendMessage(client, a.snapshot[messageType])
+		} else {
+			a.broadcastMessage(a.snapshot[messageType])
+		}
+	}
+}
+
+func (a *ApiUI) broadcast(v any) {
+	a.broadcastMessage(a.encode(v))
+}
+
+func (a *ApiUI) broadcastMessage(message []byte) {
+	a.clientMutex.Lock()
+	defer a.clientMutex.Unlock()
+
+	for client := range a.clients {
+		a.sendMessage(client, message)
+	}
+}
+
+// sendMessage drops clients that fall too far behind rather than holding up
+// the others
+func (a *ApiUI) sendMessage(client *websocketConn, message []byte) {
+	if message != nil && !client.queue(message) {
+		client.close()
+	}
+}
+
+func (a *ApiUI) encode(v any) []byte {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("Error marshalling to JSON: " + err.Error())
+		return nil
+	}
+
+	return jsonBytes
+}
+
+func (a *ApiUI) serveSnapshot(messageType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.snapshotMutex.RLock()
+		message := a.snapshot[messageType]
+		a.snapshotMutex.RUnlock()
+
+		writeJsonBytes(w, http.StatusOK, message)
+	}
+}
+
+// serveLogs returns the buffered log, ?level= limits it to a level and
+// everything more severe
+func (a *ApiUI) serveLogs(w http.ResponseWriter, r *http.Request) {
+	minLevel := apiLogAllLevels
+
+	if value := r.URL.Query().Get("level"); value != "" {
+		if err := minLevel.UnmarshalText([]byte(value)); err != nil {
+			writeJsonBytes(w, http.StatusBadRequest, a.encode(&JsonResult{MessageType: "result", Command: "logs", Error: "invalid level: " + value}))
+			return
+		}
+	}
+
+	a.logMutex.Lock()
+	logs := a.bufferedLogs(minLevel)
+	a.logMutex.Unlock()
+
+	writeJsonBytes(w, http.StatusOK, a.encode(logs))
+}
+
+// bufferedLogs must be called with the log mutex held
+func (a *ApiUI) bufferedLogs(minLevel slog.Level) *JsonLogs {
+	logs := &JsonLogs{
+		MessageType: "logs",
+		Logs:        make([]JsonLog, 0),
+	}
+
+	for _, entry := range a.logEntries {
+		if entry.level >= minLevel {
+			logs.Logs = append(logs.Logs, jsonLogEntry(entry))
+		}
+	}
+
+	return logs
+}
+
+func (a *ApiUI) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	client, err := upgradeWebsocket(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	slog.Debug("Control API client connected from " + r.RemoteAddr)
+
+	// new clients get the log so far and the current state without waiting
+	// for the next push
+	a.logMutex.Lock()
+	a.clientMutex.Lock()
+	a.clients[client] = true
+	a.clientMutex.Unlock()
+	a.sendMessage(client, a.encode(a.bufferedLogs(apiLogAllLevels)))
+	a.logMutex.Unlock()
+
+	a.sendSnapshot(client)
+
+	client.readLoop()
+
+	a.clientMutex.Lock()
+	delete(a.clients, client)
+	a.clientMutex.Unlock()
+
+	slog.Debug("Control API client disconnected from " + r.RemoteAddr)
+}
+
+// serveCommand runs a command through the action handler. Markers take an
+// optional name from the name query or form value, stop takes confirm=1.
+func (a *ApiUI) serveCommand(name string, action Action, channel bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requested := action
+		args := make([]string, 0)
+
+		// a stray stop would end the show, so it pauses like MMC stop unless
+		// ending the session is confirmed
+		if action == ActionStop && r.FormValue("confirm") != "1" {
+			requested = ActionPause
+		}
+
+		if channel {
+			args = append(args, r.PathValue("channel"))
+		} else if action == ActionMarker && r.FormValue("name") != "" {
+			args = append(args, r.FormValue("name"))
+		}
+
+		result := &JsonResult{
+			MessageType: "result",
+			Command:     name,
+			Ok:          true,
+		}
+		status := http.StatusOK
+
+		if a.actionHandler == nil {
+			result.Ok = false
+			result.Error = "commands are not available"
+			status = http.StatusServiceUnavailable
+		} else if err := a.actionHandler(requested, args); err != nil {
+			result.Ok = false
+			result.Error = err.Error()
+			status = http.StatusConflict
+		}
+
+		writeJsonBytes(w, status, a.encode(result))
+	}
+}
+
+// authorize keeps other sites' pages from using the API through a browser on
+// the network and checks the token when one is set. Without a token the API
+// only listens on the loopback, see ReadConfig. The web UI itself holds
+// no session data, so it is served to anyone.
+func (a *ApiUI) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		command := strings.TrimPrefix(r.URL.Path, "/api/")
+
+		if !sameOrigin(r) {
+			slog.Warn("Control API request from " + r.Header.Get("Origin") + " rejected, it was made by another site")
+			writeJsonBytes(w, http.StatusForbidden, a.encode(&JsonResult{MessageType: "result", Command: command, Error: "cross origin requests are not allowed"}))
+			return
+		}
+
+		// a site can point its own name at 127.0.0.1, so without a token
+		// only requests addressed to the loopback itself are trusted
+		if a.options.Token == "" && !util.IsLoopbackHost(r.Host) {
+			slog.Warn("Control API request for " + r.Host + " rejected, it wasn't addressed to the loopback")
+			writeJsonBytes(w, http.StatusForbidden, a.encode(&JsonResult{MessageType: "result", Command: command, Error: "requests must be addressed to localhost unless a token is set"}))
+			return
+		}
+
+		if !a.validToken(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJsonBytes(w, http.StatusUnauthorized, a.encode(&JsonResult{MessageType: "result", Command: command, Error: "missing or invalid token"}))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validToken checks the bearer token, or the token query parameter for
+// browser websockets which can't set headers
+func (a *ApiUI) validToken(r *http.Request) bool {
+	if a.options.Token == "" {
+		return true
+	}
+
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		token = r.URL.Query().Get("token")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.options.Token)) == 1
+}
+
+func writeJsonBytes(w http.ResponseWriter, status int, message []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(message)
+}
+
+func jsonLogEntry(entry logEntry) JsonLog {
+	return JsonLog{
+		MessageType: "log",
+
+		Date:    entry.time.Format(time.RFC3339),
+		Level:   entry.level.String(),
+		Message: entry.message,
+	}
+}
@@ -0,0 +1,78 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fox-audio/model"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		host   string
+		path   string
+		header map[string]string
+		want   int
+	}{
+		{"loopback", "", "127.0.0.1:8080", "/api/status", nil, http.StatusOK},
+		{"localhost", "", "localhost:8080", "/api/status", nil, http.StatusOK},
+		{"ipv6 loopback", "", "[::1]:8080", "/api/status", nil, http.StatusOK},
+		{"rebound name", "", "evil.example:8080", "/api/pause", map[string]string{"Origin": "http://evil.example:8080"}, http.StatusForbidden},
+		{"lan address without token", "", "192.168.1.20:8080", "/api/status", nil, http.StatusForbidden},
+		{"web ui", "", "evil.example:8080", "/index.html", nil, http.StatusOK},
+		{"cross origin", "", "127.0.0.1:8080", "/api/pause", map[string]string{"Origin": "http://evil.example"}, http.StatusForbidden},
+		{"missing token", "secret", "recorder.local:8080", "/api/status", nil, http.StatusUnauthorized},
+		{"wrong token", "secret", "recorder.local:8080", "/api/status", map[string]string{"Authorization": "Bearer nope"}, http.StatusUnauthorized},
+		{"bearer token", "secret", "recorder.local:8080", "/api/status", map[string]string{"Authorization": "Bearer secret"}, http.StatusOK},
+		{"query token", "secret", "recorder.local:8080", "/api/ws?token=secret", nil, http.StatusOK},
+		{"token cross origin", "secret", "recorder.local:8080", "/api/status?token=secret", map[string]string{"Origin": "http://evil.example"}, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := &ApiUI{options: &model.ApiOptions{Token: test.token}}
+
+			handler := a.authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, test.path, nil)
+			r.Host = test.host
+			for name, value := range test.header {
+				r.Header.Set(name, value)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.want {
+				t.Fatalf("status = %d, want %d", w.Code, test.want)
+			}
+		})
+	}
+}
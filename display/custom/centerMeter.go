@@ -0,0 +1,132 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package custom
+
+import (
+	"sync"
+
+	"fox-audio/display/theme"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/gdamore/tcell/v2"
+)
+
+// CenterMeter is a horizontal indicator for values between -1 and +1, such as
+// phase correlation or stereo balance, drawn as a marker on a track with the
+// center marked.
+type CenterMeter struct {
+	*cview.Box
+
+	leftLabel  rune
+	rightLabel rune
+
+	// current value, between -1 and +1
+	value float64
+
+	trackRune  rune
+	centerRune rune
+	markerRune rune
+
+	markerColor tcell.Color
+
+	sync.RWMutex
+}
+
+// NewCenterMeter returns a new center meter with a single character label on
+// each end of the track.
+func NewCenterMeter(leftLabel rune, rightLabel rune) *CenterMeter {
+	m := &CenterMeter{
+		Box:         cview.NewBox(),
+		leftLabel:   leftLabel,
+		rightLabel:  rightLabel,
+		trackRune:   rune(9472), // ─
+		centerRune:  rune(9532), // ┼
+		markerRune:  rune(9608), // █
+		markerColor: tcell.ColorDefault,
+	}
+	m.SetBackgroundColor(cview.Styles.PrimitiveBackgroundColor)
+	return m
+}
+
+// SetValue sets the current value, clamped to -1..+1.
+func (m *CenterMeter) SetValue(value float64) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.value = max(min(value, 1), -1)
+}
+
+// GetValue gets the current value.
+func (m *CenterMeter) GetValue() float64 {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.value
+}
+
+// SetMarkerColor sets the color of the value marker.
+func (m *CenterMeter) SetMarkerColor(color tcell.Color) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.markerColor = color
+}
+
+// Draw draws this primitive onto the screen.
+func (m *CenterMeter) Draw(screen tcell.Screen) {
+	if !m.GetVisible() {
+		return
+	}
+
+	m.Box.Draw(screen)
+
+	m.RLock()
+	defer m.RUnlock()
+
+	x, y, width, height := m.GetInnerRect()
+	if width < 3 || height < 1 {
+		return
+	}
+
+	style := tcell.StyleDefault.Background(m.GetBackgroundColor())
+	labelStyle := style.Bold(true)
+	trackStyle := style.Foreground(theme.Current().Track)
+
+	screen.SetContent(x, y, m.leftLabel, nil, labelStyle)
+	screen.SetContent(x+width-1, y, m.rightLabel, nil, labelStyle)
+
+	trackWidth := width - 2
+	center := (trackWidth - 1) / 2
+	marker := int((m.value+1)/2*float64(trackWidth-1) + 0.5)
+
+	for w := 0; w < trackWidth; w++ {
+		r := m.trackRune
+		if w == center {
+			r = m.centerRune
+		}
+
+		screen.SetContent(x+1+w, y, r, nil, trackStyle)
+	}
+
+	screen.SetContent(x+1+marker, y, m.markerRune, nil, style.Foreground(m.markerColor))
+}
@@ -28,7 +28,6 @@ import (
 	"slices"
 	"sort"
 	"sync"
-	"time"
 
 	"fox-audio/display/theme"
 
@@ -52,12 +51,14 @@ type LevelMeter struct {
 	channelNumber string
 	channelArmed  bool
 
-	// Current levels
-	level            int
-	peakLevel        int
-	peakHoldTimeMs   int
-	lastPeakTime     int64
-	longTermMaxLevel int
+	// clip indicator, latched until cleared
+	clipped bool
+
+	// Current levels in dBFS: RMS body, sample peak and peak hold line
+	level            float64
+	peakLevel        float64
+	holdLevel        float64
+	longTermMaxLevel float64
 
 	// Maximum level passable to the level meter
 	maxLevel int
@@ -68,8 +69,6 @@ type LevelMeter struct {
 	// slice containing meter level steps
 	meterSteps []int
 
-	disarmedColor tcell.Color
-
 	// meter level to foreground color map
 	colorMap map[int]tcell.Color
 
@@ -85,11 +84,10 @@ func NewLevelMeter(meterSteps []int, colorMap map[int]tcell.Color) *LevelMeter {
 		filledRune:       rune(9607), // ▇
 		maxLevel:         slices.Max(meterSteps),
 		minLevel:         slices.Min(meterSteps),
-		peakHoldTimeMs:   750,
 		peakLevel:        -150,
+		holdLevel:        -150,
 		level:            -150,
 		longTermMaxLevel: -150,
-		disarmedColor:    theme.LevelMeterDisarmdFillColor,
 		channelNumber:    "",
 		channelArmed:     false,
 		meterSteps:       meterSteps,
@@ -130,70 +128,72 @@ func (p *LevelMeter) SetFilledRune(filled rune) {
 	p.filledRune = filled
 }
 
-func (p *LevelMeter) SetLongTermMaxLevel(level int) {
+func (p *LevelMeter) SetLongTermMaxLevel(level float64) {
 	p.Lock()
 	defer p.Unlock()
 
-	p.longTermMaxLevel = level
-
-	if p.longTermMaxLevel < p.minLevel {
-		p.longTermMaxLevel = p.minLevel
-	}
+	p.longTermMaxLevel = max(level, float64(p.minLevel))
 }
 
-func (p *LevelMeter) GetLongTermMaxLevel() int {
+func (p *LevelMeter) GetLongTermMaxLevel() float64 {
 	p.RLock()
 	defer p.RUnlock()
 
 	return p.longTermMaxLevel
 }
 
-func (p *LevelMeter) SetPeakHoldTime(time int) {
+// SetMeterSteps replaces the steps drawn by the meter, one row per step.
+func (p *LevelMeter) SetMeterSteps(meterSteps []int) {
 	p.Lock()
 	defer p.Unlock()
 
-	p.peakHoldTimeMs = time
+	p.meterSteps = meterSteps
+	p.maxLevel = slices.Max(meterSteps)
 }
 
-func (p *LevelMeter) SetMinLevel(level int) {
+// SetColorMap replaces the meter level to foreground color map.
+func (p *LevelMeter) SetColorMap(colorMap map[int]tcell.Color) {
 	p.Lock()
 	defer p.Unlock()
 
-	p.minLevel = level
+	p.colorMap = colorMap
 }
 
-// SetLevel sets the current level.
-func (p *LevelMeter) SetLevel(level int) {
+func (p *LevelMeter) SetMinLevel(level int) {
 	p.Lock()
 	defer p.Unlock()
 
-	p.level = level
+	p.minLevel = level
+}
 
-	if p.level < p.minLevel {
-		p.level = p.minLevel
-	} else if p.level > p.maxLevel {
-		p.level = p.maxLevel
-	}
+// SetLevels sets the RMS level drawn as the meter body, the sample peak and
+// the peak hold line.
+func (p *LevelMeter) SetLevels(rms float64, peak float64, hold float64) {
+	p.Lock()
+	defer p.Unlock()
 
-	if p.level > p.longTermMaxLevel {
-		p.longTermMaxLevel = p.level
-	}
+	p.level = p.clampLevel(rms)
+	p.peakLevel = p.clampLevel(peak)
+	p.holdLevel = p.clampLevel(hold)
 
-	if p.level > p.peakLevel || (time.Now().UnixMilli()-p.lastPeakTime) > int64(p.peakHoldTimeMs) {
-		p.peakLevel = p.level
-		p.lastPeakTime = time.Now().UnixMilli()
+	if p.peakLevel > p.longTermMaxLevel {
+		p.longTermMaxLevel = p.peakLevel
 	}
 }
 
-// GetLevel gets the current level.
-func (p *LevelMeter) GetLevel() int {
+// GetLevel gets the current RMS level.
+func (p *LevelMeter) GetLevel() float64 {
 	p.RLock()
 	defer p.RUnlock()
 
 	return p.level
 }
 
-func getLevelColor(colorMap map[int]tcell.Color, currentLevel int) tcell.Color {
+func (p *LevelMeter) clampLevel(level float64) float64 {
+	return min(max(level, float64(p.minLevel)), float64(p.maxLevel))
+}
+
+func getLevelColor(colorMap map[int]tcell.Color, currentLevel float64) tcell.Color {
 
 	keys := make([]int, 0, len(colorMap))
 
@@ -205,7 +205,7 @@ func getLevelColor(colorMap map[int]tcell.Color, currentLevel int) tcell.Color {
 	for key := range keys {
 		mapLevel := keys[key]
 		mapColor := colorMap[mapLevel]
-		if currentLevel >= mapLevel {
+		if currentLevel >= float64(mapLevel) {
 			return mapColor
 		}
 	}
@@ -213,6 +213,36 @@ func getLevelColor(colorMap map[int]tcell.Color, currentLevel int) tcell.Color {
 	return tcell.ColorPurple
 }
 
+// SetClipped latches the clip indicator. It stays lit until ClearClip is called.
+func (p *LevelMeter) SetClipped() {
+	p.Lock()
+	defer p.Unlock()
+
+	p.clipped = true
+}
+
+// ClearClip turns off the clip indicator.
+func (p *LevelMeter) ClearClip() {
+	p.Lock()
+	defer p.Unlock()
+
+	p.clipped = false
+}
+
+func (p *LevelMeter) IsArmed() bool {
+	p.RLock()
+	defer p.RUnlock()
+
+	return p.channelArmed
+}
+
+func (p *LevelMeter) IsClipped() bool {
+	p.RLock()
+	defer p.RUnlock()
+
+	return p.clipped
+}
+
 func (p *LevelMeter) ArmChannel(armed bool) {
 	p.Lock()
 	defer p.Unlock()
@@ -232,45 +262,64 @@ func (p *LevelMeter) Draw(screen tcell.Screen) {
 	defer p.Unlock()
 
 	x, y, meterWidth, _ := p.GetInnerRect()
-	foundPeak := false
+	foundHold := false
 
 	// if len(p.channelNumber) > 0 {
 	fmtString := fmt.Sprintf("%%%dv", meterWidth)
 	runeArray := []rune(fmt.Sprintf(fmtString, p.channelNumber))
+	labelStyle := tcell.StyleDefault.Bold(true).Background(p.GetBackgroundColor())
+	if !p.channelArmed {
+		labelStyle = labelStyle.Bold(false).Foreground(theme.Current().MeterDisarmed)
+	}
+
 	for w := 0; w < meterWidth; w++ {
-		screen.SetContent(x+w, y, runeArray[w], nil, tcell.StyleDefault.Bold(true).Background(p.GetBackgroundColor()))
+		screen.SetContent(x+w, y, runeArray[w], nil, labelStyle)
 	}
 	// }
 
 	y += 1
 
+	// clip indicator
+	clipStyle := tcell.StyleDefault.Foreground(theme.Current().MeterClip).Background(p.GetBackgroundColor())
+	for w := 0; w < meterWidth; w++ {
+		if p.clipped {
+			screen.SetContent(x+w, y, p.filledRune, nil, clipStyle.Bold(true))
+		} else {
+			screen.SetContent(x+w, y, p.emptyRune, nil, clipStyle.Dim(true))
+		}
+	}
+
+	y += 1
+
 	for step := 0; step < len(p.meterSteps); step++ {
-		stepLevel := p.meterSteps[step]
+		stepLevel := float64(p.meterSteps[step])
 		doDraw := false
 		foregroundColor := getLevelColor(p.colorMap, stepLevel)
 		style := tcell.StyleDefault.Foreground(foregroundColor).Background(p.GetBackgroundColor())
 
-		if !foundPeak && p.peakLevel >= stepLevel {
-			foundPeak = true
-			style = tcell.StyleDefault.Bold(true).Foreground(foregroundColor).Background(p.GetBackgroundColor())
+		dim := !p.channelArmed
+
+		if !foundHold && p.holdLevel >= stepLevel {
+			// peak hold line
+			foundHold = true
+			style = style.Bold(true)
+			doDraw = true
+		} else if p.level >= stepLevel {
+			// rms body
+			doDraw = true
+		} else if p.peakLevel >= stepLevel {
+			// space between the rms body and the sample peak
+			dim = true
 			doDraw = true
-		} else {
-			if p.level >= stepLevel {
-				doDraw = true
-			}
 		}
 
 		if !p.channelArmed {
-			if doDraw {
-				style = style.Foreground(p.disarmedColor)
-			} else {
-				style = style.Foreground(p.disarmedColor).Dim(true)
-			}
+			style = style.Foreground(theme.Current().MeterDisarmed)
 		}
 
 		if doDraw {
 			for w := 0; w < meterWidth; w++ {
-				screen.SetContent(x+w, y+(step), p.filledRune, nil, style.Dim(!p.channelArmed))
+				screen.SetContent(x+w, y+(step), p.filledRune, nil, style.Dim(dim))
 			}
 		} else {
 			for w := 0; w < meterWidth; w++ {
@@ -282,9 +331,8 @@ func (p *LevelMeter) Draw(screen tcell.Screen) {
 	y += len(p.meterSteps)
 
 	// show max value
-	// maxValue := int(math.Abs(float64(p.longTermMaxLevel)))
 	fmtString = fmt.Sprintf("%%%dv", meterWidth)
-	runeArray = []rune(fmt.Sprintf(fmtString, math.Abs(float64(p.longTermMaxLevel))))
+	runeArray = []rune(fmt.Sprintf(fmtString, fmt.Sprintf("%.0f", math.Abs(p.longTermMaxLevel))))
 	longTermMaxColor := getLevelColor(p.colorMap, p.longTermMaxLevel)
 	for w := 0; w < meterWidth; w++ {
 		screen.SetContent(x+w, y, runeArray[w], nil, tcell.StyleDefault.Bold(true).Foreground(longTermMaxColor).Background(p.GetBackgroundColor()))
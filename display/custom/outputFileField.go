@@ -25,6 +25,7 @@ package custom
 import (
 	"strings"
 
+	"fox-audio/display/theme"
 	"fox-audio/model"
 	"fox-audio/util"
 
@@ -62,6 +63,7 @@ func NewOutputFileField(portsWidth int, sizeWidth int, outputFile model.UiOutput
 	field.SetPorts(outputFile.Ports)
 	field.SetName(outputFile.Name)
 	field.SetSize(outputFile.Size)
+	field.SetHealth(outputFile.Health)
 
 	return &field
 }
@@ -81,6 +83,21 @@ func (field *OutputFileField) SetSize(size uint64) {
 	field.sizeView.Write([]byte(util.FormatSize(size)))
 }
 
+func (field *OutputFileField) SetHealth(health model.FileHealth) {
+	color := cview.Styles.PrimaryTextColor
+
+	if health == model.FileHealthRetrying {
+		color = theme.Current().StatusWarn
+	} else if health == model.FileHealthFailed {
+		color = theme.Current().StatusError
+	} else if health == model.FileHealthDisabled {
+		color = theme.Current().Muted
+	}
+
+	field.nameView.SetTextColor(color)
+	field.sizeView.SetTextColor(color)
+}
+
 func (field *OutputFileField) GetGrid() *cview.Grid {
 	return field.grid
 }
@@ -0,0 +1,173 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package custom
+
+import (
+	"math"
+	"sync"
+
+	"fox-audio/display/theme"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/gdamore/tcell/v2"
+)
+
+// SpectrumView draws band levels as vertical bars with a peak hold marker
+// above each bar and the band labels along the bottom row.
+type SpectrumView struct {
+	*cview.Box
+
+	title  string
+	labels []string
+	levels []float64
+	peaks  []float64
+
+	minLevel  float64
+	warnLevel float64
+	clipLevel float64
+
+	peakColor tcell.Color
+
+	sync.RWMutex
+}
+
+// partial blocks for the top of each bar, in 1/8 steps
+var spectrumPartialRunes = []rune{' ', '▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
+// NewSpectrumView returns a new spectrum view showing levels down to minLevel.
+func NewSpectrumView(minLevel float64) *SpectrumView {
+	s := &SpectrumView{
+		Box:       cview.NewBox(),
+		minLevel:  minLevel,
+		warnLevel: -12,
+		clipLevel: -3,
+		peakColor: tcell.ColorDefault,
+	}
+	s.SetBackgroundColor(cview.Styles.PrimitiveBackgroundColor)
+	return s
+}
+
+// SetTitle sets the text drawn on the first row.
+func (s *SpectrumView) SetTitle(title string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.title = title
+}
+
+// SetLabels sets the label of each band, empty labels are skipped.
+func (s *SpectrumView) SetLabels(labels []string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.labels = labels
+}
+
+// SetLevels sets the current and peak hold level of each band, in dB.
+func (s *SpectrumView) SetLevels(levels []float64, peaks []float64) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.levels = levels
+	s.peaks = peaks
+}
+
+// Draw draws this primitive onto the screen.
+func (s *SpectrumView) Draw(screen tcell.Screen) {
+	if !s.GetVisible() {
+		return
+	}
+
+	s.Box.Draw(screen)
+
+	s.RLock()
+	defer s.RUnlock()
+
+	x, y, width, height := s.GetInnerRect()
+	style := tcell.StyleDefault.Background(s.GetBackgroundColor())
+
+	cview.Print(screen, []byte(s.title), x, y, width, cview.AlignLeft, tcell.ColorDefault)
+
+	bands := len(s.labels)
+	barRows := height - 2
+	if bands == 0 || barRows < 1 || width < bands {
+		return
+	}
+
+	// bars are separated by a gap when there is room
+	columnWidth := width / bands
+	barWidth := max(columnWidth-1, 1)
+
+	bottom := y + height - 2
+
+	for band := range bands {
+		left := x + band*columnWidth
+
+		level := s.minLevel
+		if band < len(s.levels) {
+			level = s.levels[band]
+		}
+
+		peak := s.minLevel
+		if band < len(s.peaks) {
+			peak = s.peaks[band]
+		}
+
+		eighths := int(math.Round(s.levelFraction(level) * float64(barRows*8)))
+		peakRow := int(math.Ceil(s.levelFraction(peak)*float64(barRows))) - 1
+
+		for row := range barRows {
+			r := spectrumPartialRunes[max(min(eighths-row*8, 8), 0)]
+			color := s.rowColor(row, barRows)
+
+			if r == ' ' && row == peakRow {
+				r = '▔'
+				color = s.peakColor
+			}
+
+			for w := range barWidth {
+				screen.SetContent(left+w, bottom-row, r, nil, style.Foreground(color))
+			}
+		}
+
+		if band < len(s.labels) && s.labels[band] != "" {
+			cview.Print(screen, []byte(s.labels[band]), left, y+height-1, columnWidth*3, cview.AlignLeft, tcell.ColorDefault)
+		}
+	}
+}
+
+func (s *SpectrumView) levelFraction(level float64) float64 {
+	return max(min((level-s.minLevel)/-s.minLevel, 1), 0)
+}
+
+func (s *SpectrumView) rowColor(row int, rows int) tcell.Color {
+	level := s.minLevel - s.minLevel*float64(row+1)/float64(rows)
+
+	if level > s.clipLevel {
+		return theme.Current().MeterClip
+	} else if level > s.warnLevel {
+		return theme.Current().MeterWarn
+	}
+
+	return theme.Current().MeterLow
+}
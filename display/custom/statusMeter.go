@@ -25,6 +25,8 @@ package custom
 import (
 	"fmt"
 
+	"fox-audio/display/theme"
+
 	"code.rocketnine.space/tslocum/cview"
 	"github.com/gdamore/tcell/v2"
 )
@@ -63,7 +65,7 @@ func NewStatusMeter(headerWidth int, name string, initialValue int, unit string)
 	meter.meterView = cview.NewProgressBar()
 	meter.meterView.SetFilledRune(rune(9607))
 	meter.meterView.SetEmptyRune(rune(9617))
-	meter.meterView.SetEmptyColor(tcell.Color242)
+	meter.meterView.SetEmptyColor(theme.Current().Track)
 	meter.grid.AddItem(meter.meterView, 0, 1, 1, 1, 0, 0, false)
 
 	if meter.showValue {
@@ -95,6 +97,10 @@ func (meter *StatusMeter) SetColor(color tcell.Color) {
 	meter.valueView.SetTextColor(color)
 }
 
+func (meter *StatusMeter) SetEmptyColor(color tcell.Color) {
+	meter.meterView.SetEmptyColor(color)
+}
+
 func (meter *StatusMeter) GetGrid() *cview.Grid {
 	return meter.grid
 }
@@ -0,0 +1,228 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"fox-audio/model"
+
+	"code.rocketnine.space/tslocum/cview"
+)
+
+//
+// types
+//
+
+// DaemonUI has nothing to show, it only writes the log. When journal is set
+// each line carries a syslog priority prefix for journald instead of a time.
+type DaemonUI struct {
+	shutdownChannel chan bool
+
+	output  *os.File
+	journal bool
+}
+
+//
+// constructor
+//
+
+func NewDaemonUI(output *os.File, journal bool) *DaemonUI {
+	return &DaemonUI{
+		shutdownChannel: make(chan bool, 1),
+
+		output:  output,
+		journal: journal,
+	}
+}
+
+func (d *DaemonUI) Initalize() {
+	// nothing to do here
+}
+
+func (d *DaemonUI) Start() {
+	// nothing to do here
+}
+
+func (d *DaemonUI) Shutdown() {
+	slog.Debug("Shutting down daemon UI")
+	d.shutdownChannel <- true
+}
+
+func (d *DaemonUI) IsShutdown() bool {
+	return len(d.shutdownChannel) > 0
+}
+
+func (d *DaemonUI) WaitForShutdown() {
+	<-d.shutdownChannel
+}
+
+func (d *DaemonUI) SetTransportStatus(status Status) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetDuration(duration float64) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetAudioFormat(format string) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetProfileName(value string) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetTakeName(value string) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetDirectory(value string) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetSessionSize(size uint64) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) IncrementErrorCount() {
+	// nothing to do here
+}
+
+func (d *DaemonUI) UpdateSignalLevels(levels []model.SignalLevel) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetChannelArmStatus(channel int, armed bool) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetOutputFiles(outputFiles []model.UiOutputFile) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) UpdateOutputFileSizes(sizes []uint64) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) UpdateOutputFileHealth(health []model.FileHealth) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetTheme(options *model.ThemeOptions) error {
+	// nothing to do here
+	return nil
+}
+
+func (d *DaemonUI) SetMeterStandard(options *model.MeteringOptions) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetChannelCount(channelCount int) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetChannelGroups(groups []model.UiChannelGroup) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) UpdateStereoLevels(levels []model.StereoLevel) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetLoudnessMeters(names []string) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) UpdateLoudness(levels []model.LoudnessLevel) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) UpdateSpectrum(spectrum *model.Spectrum) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetActionHandler(handler ActionHandler) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) WriteLevelLog(level slog.Level, message string) {
+	if d.journal {
+		fmt.Fprintf(d.output, "<%d>%s\n", journalPriority(level), message)
+	} else {
+		fmt.Fprintf(d.output, "%s [%s] %s\n", time.Now().Format(textTimeFormat), level.String(), message)
+	}
+}
+
+func (d *DaemonUI) SetAudioLoad(percent int) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetDiskUsage(percent int) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetBufferUtilization(percent int) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetDiskLoad(percent int) {
+	// nothing to do here
+}
+
+func (d *DaemonUI) SetCycleBuffer(percent int) {
+	// nothing to do here
+}
+
+// ShowSummary writes the summary to the log, one line at a time
+func (d *DaemonUI) ShowSummary(summary *model.SessionSummary) {
+	text := string(cview.StripTags([]byte(formatSummary(summary)), true, false))
+
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if line != "" {
+			d.WriteLevelLog(slog.LevelInfo, line)
+		}
+	}
+}
+
+func (d *DaemonUI) HandlePanic() {
+	// nothing to do here
+}
+
+// journalPriority maps a log level to a syslog priority
+func journalPriority(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 4
+	case level >= slog.LevelInfo:
+		return 6
+	}
+
+	return 7
+}
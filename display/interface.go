@@ -45,12 +45,22 @@ type UI interface {
 	SetChannelArmStatus(channel int, armed bool)
 	SetOutputFiles(outputFiles []model.UiOutputFile)
 	UpdateOutputFileSizes(sizes []uint64)
+	UpdateOutputFileHealth(health []model.FileHealth)
+	SetTheme(options *model.ThemeOptions) error
+	SetMeterStandard(options *model.MeteringOptions)
 	SetChannelCount(channelCount int)
+	SetChannelGroups(groups []model.UiChannelGroup)
+	UpdateStereoLevels(levels []model.StereoLevel)
+	SetLoudnessMeters(names []string)
+	UpdateLoudness(levels []model.LoudnessLevel)
+	UpdateSpectrum(spectrum *model.Spectrum)
+	SetActionHandler(handler ActionHandler)
 	WriteLevelLog(level slog.Level, message string)
 	SetAudioLoad(percent int)
 	SetDiskUsage(percent int)
 	SetBufferUtilization(percent int)
 	SetDiskLoad(percent int)
 	SetCycleBuffer(percent int)
+	ShowSummary(summary *model.SessionSummary)
 	HandlePanic()
 }
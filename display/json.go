@@ -63,8 +63,12 @@ type JsonUI struct {
 	metricAudioLoadPct       int
 	metricDiskLoadPct        int
 
-	signalLevels []model.SignalLevel
-	outputFiles  []model.UiOutputFile
+	meterStandard string
+	signalLevels  []model.SignalLevel
+	channelArmed  []bool
+	outputFiles   []model.UiOutputFile
+	loudness      []model.LoudnessLevel
+	stereoLevels  []model.StereoLevel
 }
 
 //
@@ -94,8 +98,12 @@ func NewJsonUI(output *os.File) *JsonUI {
 		metricAudioLoadPct:       0,
 		metricDiskLoadPct:        0,
 
-		signalLevels: make([]model.SignalLevel, 0),
-		outputFiles:  make([]model.UiOutputFile, 0),
+		meterStandard: model.MeterStandardDigital,
+		signalLevels:  make([]model.SignalLevel, 0),
+		channelArmed:  make([]bool, 0),
+		outputFiles:   make([]model.UiOutputFile, 0),
+		loudness:      make([]model.LoudnessLevel, 0),
+		stereoLevels:  make([]model.StereoLevel, 0),
 		// elementLevelMeters: make([]*custom.LevelMeter, 0),
 		// elementOutputFiles: make([]*custom.OutputFileField, 0),
 	}
@@ -126,6 +134,10 @@ func (j *JsonUI) excecuteLoop() {
 		j.printJson(j.getLevels())
 		j.printJson(j.getOutputFiles())
 
+		if len(j.loudness) > 0 {
+			j.printJson(j.getLoudness())
+		}
+
 		time.Sleep(1 * time.Second)
 	}
 
@@ -181,17 +193,19 @@ func (j *JsonUI) IncrementErrorCount() {
 }
 
 func (j *JsonUI) UpdateSignalLevels(levels []model.SignalLevel) {
-	copy(levels, j.signalLevels)
+	copy(j.signalLevels, levels)
 }
 
 func (j *JsonUI) SetChannelArmStatus(channel int, armed bool) {
-	// nothing to do here
+	if channel < len(j.channelArmed) {
+		j.channelArmed[channel] = armed
+	}
 }
 
 func (j *JsonUI) SetOutputFiles(outputFiles []model.UiOutputFile) {
 	j.outputFiles = make([]model.UiOutputFile, len(outputFiles))
 
-	copy(outputFiles, j.outputFiles)
+	copy(j.outputFiles, outputFiles)
 }
 
 func (j *JsonUI) UpdateOutputFileSizes(sizes []uint64) {
@@ -200,8 +214,57 @@ func (j *JsonUI) UpdateOutputFileSizes(sizes []uint64) {
 	}
 }
 
+func (j *JsonUI) UpdateOutputFileHealth(health []model.FileHealth) {
+	for i, state := range health {
+		j.outputFiles[i].Health = state
+	}
+}
+
+func (j *JsonUI) SetTheme(options *model.ThemeOptions) error {
+	// nothing to do here
+	return nil
+}
+
+func (j *JsonUI) SetMeterStandard(options *model.MeteringOptions) {
+	j.meterStandard = options.Standard
+}
+
 func (j *JsonUI) SetChannelCount(channelCount int) {
 	j.signalLevels = make([]model.SignalLevel, channelCount)
+	j.channelArmed = make([]bool, channelCount)
+
+	// channels are armed until told otherwise, the simulation never says
+	for i := range j.channelArmed {
+		j.channelArmed[i] = true
+	}
+}
+
+func (j *JsonUI) SetChannelGroups(groups []model.UiChannelGroup) {
+	// nothing to do here
+}
+
+func (j *JsonUI) UpdateStereoLevels(levels []model.StereoLevel) {
+	j.stereoLevels = levels
+}
+
+func (j *JsonUI) SetLoudnessMeters(names []string) {
+	j.loudness = make([]model.LoudnessLevel, len(names))
+
+	for i, name := range names {
+		j.loudness[i].Name = name
+	}
+}
+
+func (j *JsonUI) UpdateLoudness(levels []model.LoudnessLevel) {
+	j.loudness = levels
+}
+
+func (j *JsonUI) UpdateSpectrum(spectrum *model.Spectrum) {
+	// the spectrum is only shown in the TUI
+}
+
+func (j *JsonUI) SetActionHandler(handler ActionHandler) {
+	// nothing to do here
 }
 
 func (j *JsonUI) WriteLevelLog(level slog.Level, message string) {
@@ -236,6 +299,10 @@ func (j *JsonUI) SetCycleBuffer(percent int) {
 	j.metricCycleBufferUsedPct = percent
 }
 
+func (j *JsonUI) ShowSummary(summary *model.SessionSummary) {
+	j.printJson(jsonSummary(summary))
+}
+
 func (j *JsonUI) HandlePanic() {
 	// TODO: what should we do here?
 }
@@ -282,12 +349,30 @@ func (j *JsonUI) getLevels() *JsonLevels {
 	jsonLevels := &JsonLevels{
 		MessageType: "levels",
 
-		Ports: make([]JsonLevelPort, len(j.signalLevels)),
+		Standard: j.meterStandard,
+		Ports:    make([]JsonLevelPort, len(j.signalLevels)),
+		Stereo:   make([]JsonStereoLevel, len(j.stereoLevels)),
+	}
+
+	for i, level := range j.stereoLevels {
+		jsonLevels.Stereo[i].Name = level.Name
+		jsonLevels.Stereo[i].Ports = make([]string, len(level.Ports))
+		jsonLevels.Stereo[i].Correlation = level.Correlation
+		jsonLevels.Stereo[i].Balance = level.Balance
+
+		for p, port := range level.Ports {
+			jsonLevels.Stereo[i].Ports[p] = fmt.Sprintf("%d", port+1)
+		}
 	}
 
 	for i, level := range j.signalLevels {
 		jsonLevels.Ports[i].Name = fmt.Sprintf("%d", i+1)
-		jsonLevels.Ports[i].Level = level.Instant
+		jsonLevels.Ports[i].Level = level.Max
+		jsonLevels.Ports[i].Rms = level.Instant
+		jsonLevels.Ports[i].PeakHold = level.Peak
+		jsonLevels.Ports[i].Meter = level.Meter
+		jsonLevels.Ports[i].Clips = level.ClipCount
+		jsonLevels.Ports[i].Armed = i < len(j.channelArmed) && j.channelArmed[i]
 	}
 
 	return jsonLevels
@@ -304,7 +389,60 @@ func (j *JsonUI) getOutputFiles() *JsonOutputFiles {
 		outputFiles.Files[i].Name = file.Name
 		outputFiles.Files[i].Ports = file.Ports
 		outputFiles.Files[i].Size = file.Size
+		outputFiles.Files[i].Health = model.FileHealthNames[file.Health]
 	}
 
 	return outputFiles
 }
+
+func (j *JsonUI) getLoudness() *JsonLoudness {
+	return &JsonLoudness{
+		MessageType: "loudness",
+
+		Meters: jsonLoudnessMeters(j.loudness),
+	}
+}
+
+func jsonLoudnessMeters(levels []model.LoudnessLevel) []JsonLoudnessMeter {
+	meters := make([]JsonLoudnessMeter, len(levels))
+
+	for i, level := range levels {
+		meters[i].Name = level.Name
+		meters[i].Momentary = level.Momentary
+		meters[i].ShortTerm = level.ShortTerm
+		meters[i].Integrated = level.Integrated
+		meters[i].Range = level.Range
+		meters[i].TruePeak = level.TruePeak
+	}
+
+	return meters
+}
+
+func jsonSummary(summary *model.SessionSummary) *JsonSummary {
+	result := &JsonSummary{
+		MessageType: "summary",
+
+		ProfileName:    summary.ProfileName,
+		TakeName:       summary.Take,
+		Directory:      summary.Directory,
+		Duration:       summary.Duration,
+		XrunCount:      summary.XrunCount,
+		DroppedSamples: summary.DroppedSamples,
+		ErrorCount:     summary.ErrorCount,
+		DiskFree:       summary.DiskFree,
+
+		Files:    make([]JsonSummaryFile, len(summary.Files)),
+		Loudness: jsonLoudnessMeters(summary.Loudness),
+	}
+
+	for i, file := range summary.Files {
+		result.Files[i].Name = file.Name
+		result.Files[i].FileName = file.FileName
+		result.Files[i].Size = file.Size
+		result.Files[i].PeakDbfs = file.PeakDbfs
+		result.Files[i].ClippedSamples = file.ClippedSamples
+		result.Files[i].Health = model.FileHealthNames[file.Health]
+	}
+
+	return result
+}
@@ -50,15 +50,58 @@ type JsonLog struct {
 	Message string `json:"message"`
 }
 
+type JsonLogs struct {
+	MessageType string `json:"message_type"`
+
+	Logs []JsonLog `json:"logs"`
+}
+
+type JsonResult struct {
+	MessageType string `json:"message_type"`
+
+	Command string `json:"command"`
+	Ok      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+}
+
 type JsonLevels struct {
 	MessageType string `json:"message_type"`
 
-	Ports []JsonLevelPort `json:"ports"`
+	Standard string            `json:"standard"`
+	Ports    []JsonLevelPort   `json:"ports"`
+	Stereo   []JsonStereoLevel `json:"stereo"`
+}
+
+type JsonStereoLevel struct {
+	Name        string   `json:"name"`
+	Ports       []string `json:"ports"`
+	Correlation float64  `json:"correlation"`
+	Balance     float64  `json:"balance"`
 }
 
 type JsonLevelPort struct {
-	Name  string `json:"name"`
-	Level int    `json:"level"`
+	Name     string  `json:"name"`
+	Level    float64 `json:"level"`
+	Rms      float64 `json:"rms"`
+	Meter    float64 `json:"meter"`
+	PeakHold float64 `json:"peak_hold"`
+	Clips    uint64  `json:"clips"`
+	Armed    bool    `json:"armed"`
+}
+
+type JsonLoudness struct {
+	MessageType string `json:"message_type"`
+
+	Meters []JsonLoudnessMeter `json:"meters"`
+}
+
+type JsonLoudnessMeter struct {
+	Name       string  `json:"name"`
+	Momentary  float64 `json:"momentary"`
+	ShortTerm  float64 `json:"short_term"`
+	Integrated float64 `json:"integrated"`
+	Range      float64 `json:"range"`
+	TruePeak   float64 `json:"true_peak"`
 }
 
 type JsonOutputFiles struct {
@@ -68,7 +111,33 @@ type JsonOutputFiles struct {
 }
 
 type JsonOutputFile struct {
-	Name  string   `json:"name"`
-	Ports []string `json:"ports"`
-	Size  uint64   `json:"size"`
+	Name   string   `json:"name"`
+	Ports  []string `json:"ports"`
+	Size   uint64   `json:"size"`
+	Health string   `json:"health"`
+}
+
+type JsonSummary struct {
+	MessageType string `json:"message_type"`
+
+	ProfileName    string  `json:"profile_name"`
+	TakeName       string  `json:"take_name"`
+	Directory      string  `json:"directory"`
+	Duration       float64 `json:"duration"`
+	XrunCount      int     `json:"xrun_count"`
+	DroppedSamples uint64  `json:"dropped_samples"`
+	ErrorCount     int     `json:"error_count"`
+	DiskFree       uint64  `json:"disk_free"`
+
+	Files    []JsonSummaryFile   `json:"files"`
+	Loudness []JsonLoudnessMeter `json:"loudness"`
+}
+
+type JsonSummaryFile struct {
+	Name           string  `json:"name"`
+	FileName       string  `json:"file_name"`
+	Size           uint64  `json:"size"`
+	PeakDbfs       float64 `json:"peak_dbfs"`
+	ClippedSamples uint64  `json:"clipped_samples"`
+	Health         string  `json:"health"`
 }
@@ -0,0 +1,186 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"fmt"
+	"math"
+
+	"fox-audio/display/theme"
+	"fox-audio/model"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// meterScale describes how a meter standard is drawn. Steps are in dBFS so
+// the level meter can compare them with the levels it is given, and labels
+// are written in the units of the standard.
+type meterScale struct {
+	standard string
+	steps    []int
+	labels   []string
+
+	// meter level (dBFS) to foreground color map
+	colors map[int]tcell.Color
+
+	// digital meters draw the RMS level as the body, the others draw the
+	// ballistic reading
+	rmsBody bool
+}
+
+func newMeterScale(options *model.MeteringOptions) *meterScale {
+	ref := int(math.Round(options.ReferenceLevelDb))
+	palette := theme.Current()
+
+	switch options.Standard {
+	case model.MeterStandardPpmNordic:
+		// TEST (0) is aligned to the reference level, +9 is the permitted maximum
+		marks := []int{12, 9, 6, 3, 0, -3, -6, -9, -12, -15, -18, -21, -24, -30, -36, -42}
+		labels := make([]string, len(marks))
+		for i, mark := range marks {
+			labels[i] = fmt.Sprintf("%+d", mark)
+			if mark == 0 {
+				labels[i] = "TST"
+			}
+		}
+
+		return &meterScale{
+			standard: options.Standard,
+			steps:    offsetSteps(marks, ref),
+			labels:   labels,
+			colors: map[int]tcell.Color{
+				ref + 10: palette.MeterClip,
+				ref + 6:  palette.MeterWarn,
+				ref - 18: palette.MeterNormal,
+				-150:     palette.MeterLow,
+			},
+		}
+
+	case model.MeterStandardPpmBbc:
+		// mark 4 is aligned to the reference level, 4 dB per mark, peaks at mark 6
+		marks := []int{14, 12, 10, 8, 6, 4, 2, 0, -2, -4, -6, -8, -10, -12, -16, -20, -24}
+		labels := make([]string, len(marks))
+		for i, mark := range marks {
+			if mark%4 == 0 && mark >= -12 && mark <= 12 {
+				labels[i] = fmt.Sprintf("%d", 4+mark/4)
+			}
+		}
+
+		return &meterScale{
+			standard: options.Standard,
+			steps:    offsetSteps(marks, ref),
+			labels:   labels,
+			colors: map[int]tcell.Color{
+				ref + 9:  palette.MeterClip,
+				ref + 4:  palette.MeterWarn,
+				ref - 12: palette.MeterNormal,
+				-150:     palette.MeterLow,
+			},
+		}
+
+	case model.MeterStandardVu:
+		// 0 VU is aligned to the reference level
+		marks := []int{3, 2, 1, 0, -1, -2, -3, -4, -5, -6, -7, -8, -10, -12, -15, -20}
+		labels := make([]string, len(marks))
+		for i, mark := range marks {
+			labels[i] = fmt.Sprintf("%d", mark)
+		}
+
+		return &meterScale{
+			standard: options.Standard,
+			steps:    offsetSteps(marks, ref),
+			labels:   labels,
+			colors: map[int]tcell.Color{
+				ref + 1:  palette.MeterClip,
+				ref - 20: palette.MeterNormal,
+				-150:     palette.MeterLow,
+			},
+		}
+	}
+
+	steps := []int{
+		0, -1, -2, -3, -4, -6, -8,
+		-10, -12, -15, -18, -21, -24, -27,
+		-30, -36, -42, -48, -54, -60}
+
+	labels := make([]string, len(steps))
+	for i, step := range steps {
+		labels[i] = fmt.Sprintf("%d", step)
+	}
+
+	return &meterScale{
+		standard: model.MeterStandardDigital,
+		steps:    steps,
+		labels:   labels,
+		colors: map[int]tcell.Color{
+			0:    palette.MeterClip,
+			-2:   palette.MeterHigh,
+			-6:   palette.MeterWarn,
+			-18:  palette.MeterNormal,
+			-150: palette.MeterLow,
+		},
+		rmsBody: true,
+	}
+}
+
+// compact returns the scale at half height, dropping every other step. The
+// steps that keep the most labels are the ones kept.
+func (s *meterScale) compact() *meterScale {
+	parity := 0
+	labelCount := [2]int{}
+
+	for i, label := range s.labels {
+		if label != "" {
+			labelCount[i%2]++
+		}
+	}
+
+	if labelCount[1] > labelCount[0] {
+		parity = 1
+	}
+
+	compact := &meterScale{
+		standard: s.standard,
+		steps:    make([]int, 0, len(s.steps)/2+1),
+		labels:   make([]string, 0, len(s.labels)/2+1),
+		colors:   s.colors,
+		rmsBody:  s.rmsBody,
+	}
+
+	for i := parity; i < len(s.steps); i += 2 {
+		compact.steps = append(compact.steps, s.steps[i])
+		compact.labels = append(compact.labels, s.labels[i])
+	}
+
+	return compact
+}
+
+func offsetSteps(marks []int, ref int) []int {
+	steps := make([]int, len(marks))
+
+	for i, mark := range marks {
+		steps[i] = mark + ref
+	}
+
+	return steps
+}
@@ -0,0 +1,265 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"log/slog"
+	"sync"
+
+	"fox-audio/model"
+)
+
+// MultiUI passes every update on to several displays, the first one is the
+// display the operator interacts with
+type MultiUI struct {
+	uis []UI
+}
+
+func NewMultiUI(uis ...UI) *MultiUI {
+	return &MultiUI{
+		uis: uis,
+	}
+}
+
+func (m *MultiUI) Initalize() {
+	for _, ui := range m.uis {
+		ui.Initalize()
+	}
+}
+
+func (m *MultiUI) Start() {
+	for _, ui := range m.uis {
+		ui.Start()
+	}
+}
+
+func (m *MultiUI) Shutdown() {
+	for _, ui := range m.uis {
+		ui.Shutdown()
+	}
+}
+
+func (m *MultiUI) IsShutdown() bool {
+	return m.uis[0].IsShutdown()
+}
+
+func (m *MultiUI) WaitForShutdown() {
+	for _, ui := range m.uis {
+		ui.WaitForShutdown()
+	}
+}
+
+func (m *MultiUI) SetTransportStatus(status Status) {
+	for _, ui := range m.uis {
+		ui.SetTransportStatus(status)
+	}
+}
+
+func (m *MultiUI) SetDuration(duration float64) {
+	for _, ui := range m.uis {
+		ui.SetDuration(duration)
+	}
+}
+
+func (m *MultiUI) SetAudioFormat(format string) {
+	for _, ui := range m.uis {
+		ui.SetAudioFormat(format)
+	}
+}
+
+func (m *MultiUI) SetProfileName(value string) {
+	for _, ui := range m.uis {
+		ui.SetProfileName(value)
+	}
+}
+
+func (m *MultiUI) SetTakeName(value string) {
+	for _, ui := range m.uis {
+		ui.SetTakeName(value)
+	}
+}
+
+func (m *MultiUI) SetDirectory(value string) {
+	for _, ui := range m.uis {
+		ui.SetDirectory(value)
+	}
+}
+
+func (m *MultiUI) SetSessionSize(size uint64) {
+	for _, ui := range m.uis {
+		ui.SetSessionSize(size)
+	}
+}
+
+func (m *MultiUI) IncrementErrorCount() {
+	for _, ui := range m.uis {
+		ui.IncrementErrorCount()
+	}
+}
+
+func (m *MultiUI) UpdateSignalLevels(levels []model.SignalLevel) {
+	for _, ui := range m.uis {
+		ui.UpdateSignalLevels(levels)
+	}
+}
+
+func (m *MultiUI) SetChannelArmStatus(channel int, armed bool) {
+	for _, ui := range m.uis {
+		ui.SetChannelArmStatus(channel, armed)
+	}
+}
+
+func (m *MultiUI) SetOutputFiles(outputFiles []model.UiOutputFile) {
+	for _, ui := range m.uis {
+		ui.SetOutputFiles(outputFiles)
+	}
+}
+
+func (m *MultiUI) UpdateOutputFileSizes(sizes []uint64) {
+	for _, ui := range m.uis {
+		ui.UpdateOutputFileSizes(sizes)
+	}
+}
+
+func (m *MultiUI) UpdateOutputFileHealth(health []model.FileHealth) {
+	for _, ui := range m.uis {
+		ui.UpdateOutputFileHealth(health)
+	}
+}
+
+func (m *MultiUI) SetTheme(options *model.ThemeOptions) error {
+	for _, ui := range m.uis {
+		if err := ui.SetTheme(options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MultiUI) SetMeterStandard(options *model.MeteringOptions) {
+	for _, ui := range m.uis {
+		ui.SetMeterStandard(options)
+	}
+}
+
+func (m *MultiUI) SetChannelCount(channelCount int) {
+	for _, ui := range m.uis {
+		ui.SetChannelCount(channelCount)
+	}
+}
+
+func (m *MultiUI) SetChannelGroups(groups []model.UiChannelGroup) {
+	for _, ui := range m.uis {
+		ui.SetChannelGroups(groups)
+	}
+}
+
+func (m *MultiUI) UpdateStereoLevels(levels []model.StereoLevel) {
+	for _, ui := range m.uis {
+		ui.UpdateStereoLevels(levels)
+	}
+}
+
+func (m *MultiUI) SetLoudnessMeters(names []string) {
+	for _, ui := range m.uis {
+		ui.SetLoudnessMeters(names)
+	}
+}
+
+func (m *MultiUI) UpdateLoudness(levels []model.LoudnessLevel) {
+	for _, ui := range m.uis {
+		ui.UpdateLoudness(levels)
+	}
+}
+
+func (m *MultiUI) UpdateSpectrum(spectrum *model.Spectrum) {
+	for _, ui := range m.uis {
+		ui.UpdateSpectrum(spectrum)
+	}
+}
+
+func (m *MultiUI) SetActionHandler(handler ActionHandler) {
+	for _, ui := range m.uis {
+		ui.SetActionHandler(handler)
+	}
+}
+
+func (m *MultiUI) WriteLevelLog(level slog.Level, message string) {
+	for _, ui := range m.uis {
+		ui.WriteLevelLog(level, message)
+	}
+}
+
+func (m *MultiUI) SetAudioLoad(percent int) {
+	for _, ui := range m.uis {
+		ui.SetAudioLoad(percent)
+	}
+}
+
+func (m *MultiUI) SetDiskUsage(percent int) {
+	for _, ui := range m.uis {
+		ui.SetDiskUsage(percent)
+	}
+}
+
+func (m *MultiUI) SetBufferUtilization(percent int) {
+	for _, ui := range m.uis {
+		ui.SetBufferUtilization(percent)
+	}
+}
+
+func (m *MultiUI) SetDiskLoad(percent int) {
+	for _, ui := range m.uis {
+		ui.SetDiskLoad(percent)
+	}
+}
+
+func (m *MultiUI) SetCycleBuffer(percent int) {
+	for _, ui := range m.uis {
+		ui.SetCycleBuffer(percent)
+	}
+}
+
+// ShowSummary shows the summary on every UI at once, as the TUI blocks until
+// the operator dismisses it
+func (m *MultiUI) ShowSummary(summary *model.SessionSummary) {
+	var wg sync.WaitGroup
+
+	for _, ui := range m.uis {
+		wg.Add(1)
+
+		go func(ui UI) {
+			defer wg.Done()
+			ui.ShowSummary(summary)
+		}(ui)
+	}
+
+	wg.Wait()
+}
+
+func (m *MultiUI) HandlePanic() {
+	for _, ui := range m.uis {
+		ui.HandlePanic()
+	}
+}
@@ -0,0 +1,322 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"fox-audio/model"
+	"fox-audio/osc"
+	"fox-audio/util"
+)
+
+const (
+	oscMaxPacketSize = 65536
+)
+
+//
+// types
+//
+
+// OscUI takes commands over OSC and sends the transport status, elapsed time
+// and levels to the feedback destinations. It runs alongside another display,
+// see MultiUI.
+type OscUI struct {
+	// keeps the state exactly the way the JSON output reports it
+	*JsonUI
+
+	options      *model.OscOptions
+	conn         *net.UDPConn
+	destinations []*net.UDPAddr
+	done         chan bool
+
+	actionHandler ActionHandler
+}
+
+//
+// constructor
+//
+
+func NewOscUI(options *model.OscOptions) *OscUI {
+	return &OscUI{
+		JsonUI: NewJsonUI(nil),
+
+		options:      options,
+		destinations: make([]*net.UDPAddr, 0),
+		done:         make(chan bool),
+	}
+}
+
+// Listen opens the socket and resolves the feedback destinations so problems
+// are reported before the session starts. Without a listen address the socket
+// is only used to send feedback.
+func (o *OscUI) Listen() error {
+	for _, destination := range o.options.Feedback {
+		address, err := net.ResolveUDPAddr("udp", destination)
+		if err != nil {
+			return errors.New("invalid OSC feedback destination " + destination + ": " + err.Error())
+		}
+
+		o.destinations = append(o.destinations, address)
+	}
+
+	var listenAddress *net.UDPAddr
+
+	if o.options.Listen != "" {
+		address, err := net.ResolveUDPAddr("udp", o.options.Listen)
+		if err != nil {
+			return errors.New("invalid OSC listen address " + o.options.Listen + ": " + err.Error())
+		}
+
+		listenAddress = address
+	}
+
+	conn, err := net.ListenUDP("udp", listenAddress)
+	if err != nil {
+		return errors.New("failed to listen on " + o.options.Listen + ": " + err.Error())
+	}
+
+	o.conn = conn
+
+	return nil
+}
+
+func (o *OscUI) Initalize() {
+	// nothing to do here
+}
+
+func (o *OscUI) Start() {
+	if o.options.Listen != "" {
+		go o.receiveLoop()
+	}
+
+	if len(o.destinations) > 0 {
+		go o.feedbackLoop()
+	}
+}
+
+func (o *OscUI) Shutdown() {
+	slog.Debug("Shutting down OSC server")
+	close(o.done)
+
+	o.conn.Close()
+}
+
+func (o *OscUI) IsShutdown() bool {
+	select {
+	case <-o.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (o *OscUI) WaitForShutdown() {
+	<-o.done
+}
+
+func (o *OscUI) SetActionHandler(handler ActionHandler) {
+	o.actionHandler = handler
+}
+
+func (o *OscUI) WriteLevelLog(level slog.Level, message string) {
+	// logs are not sent over OSC
+}
+
+func (o *OscUI) ShowSummary(summary *model.SessionSummary) {
+	// nothing to do here
+}
+
+func (o *OscUI) HandlePanic() {
+	// nothing to do here
+}
+
+//
+// private functions
+//
+
+func (o *OscUI) receiveLoop() {
+	slog.Info("OSC server listening on " + o.conn.LocalAddr().String())
+
+	buffer := make([]byte, oscMaxPacketSize)
+
+	for {
+		size, sender, err := o.conn.ReadFromUDP(buffer)
+		if err != nil {
+			if o.IsShutdown() || errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			slog.Warn("OSC receive failed: " + err.Error())
+			continue
+		}
+
+		messages, err := osc.Unmarshal(buffer[:size])
+		if err != nil {
+			slog.Debug("Ignoring OSC packet from " + sender.String() + ": " + err.Error())
+			continue
+		}
+
+		for _, message := range messages {
+			if err := o.handleMessage(message); err != nil {
+				slog.Warn("OSC " + message.Address + " failed: " + err.Error())
+				o.send(sender, osc.NewMessage("/fox/error", message.Address, err.Error()))
+			}
+		}
+	}
+}
+
+func (o *OscUI) handleMessage(message *osc.Message) error {
+	if oscReleased(message) {
+		return nil
+	}
+
+	switch message.Address {
+	case "/fox/record":
+		return o.runAction(ActionRecord)
+	case "/fox/pause":
+		return o.runAction(ActionPause)
+	case "/fox/stop":
+		// a button sends a number, so a stray press pauses like MMC stop and
+		// only the confirm label ends the session
+		if label, ok := message.String(0); ok && label == "confirm" {
+			return o.runAction(ActionStop)
+		}
+		return o.runAction(ActionPause)
+	case "/fox/take/new":
+		return o.runAction(ActionNewTake)
+	case "/fox/marker":
+		// buttons send a number, only strings are taken as the label
+		if len(message.Arguments) > 0 {
+			if label, ok := message.Arguments[0].(string); ok && label != "" {
+				return o.runAction(ActionMarker, label)
+			}
+		}
+		return o.runAction(ActionMarker)
+	case "/fox/arm":
+		channel, ok := message.String(0)
+		if !ok {
+			return errors.New("expected a channel number or name")
+		}
+
+		if state, ok := message.Float(1); ok && state == 0 {
+			return o.runAction(ActionDisarm, channel)
+		}
+		return o.runAction(ActionArm, channel)
+	}
+
+	slog.Debug("Ignoring unknown OSC address " + message.Address)
+
+	return nil
+}
+
+func (o *OscUI) runAction(action Action, args ...string) error {
+	if o.actionHandler == nil {
+		return errors.New("commands are not available")
+	}
+
+	return o.actionHandler(action, args)
+}
+
+func (o *OscUI) feedbackLoop() {
+	ticker := time.NewTicker(time.Duration(o.options.FeedbackIntervalMs) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.done:
+			return
+		case <-ticker.C:
+			messages := o.feedback()
+
+			for _, destination := range o.destinations {
+				o.send(destination, messages...)
+			}
+		}
+	}
+}
+
+// feedback builds the messages sent on every interval. Levels are in dBFS and
+// follow the meter standard, the same as the meter bridge.
+func (o *OscUI) feedback() []*osc.Message {
+	recording := int32(0)
+	if o.statusTransport == StatusRecording {
+		recording = 1
+	}
+
+	messages := []*osc.Message{
+		osc.NewMessage("/fox/status", statusNames[o.statusTransport]),
+		osc.NewMessage("/fox/recording", recording),
+		osc.NewMessage("/fox/take", o.statusTakeName),
+		osc.NewMessage("/fox/elapsed", float32(o.statusDuration)),
+		osc.NewMessage("/fox/elapsed/text", util.FormatDuration(o.statusDuration)),
+	}
+
+	rmsBody := o.meterStandard == model.MeterStandardDigital
+
+	for i, level := range o.signalLevels {
+		value := level.Meter
+		if rmsBody {
+			value = level.Instant
+		}
+
+		messages = append(messages, osc.NewMessage("/fox/level/"+strconv.Itoa(i+1), float32(value)))
+	}
+
+	return messages
+}
+
+func (o *OscUI) send(destination *net.UDPAddr, messages ...*osc.Message) {
+	for _, message := range messages {
+		packet, err := message.Marshal()
+		if err != nil {
+			slog.Error("Error encoding OSC message: " + err.Error())
+			continue
+		}
+
+		if _, err := o.conn.WriteToUDP(packet, destination); err != nil && !o.IsShutdown() {
+			slog.Debug("Failed to send OSC to " + destination.String() + ": " + err.Error())
+			return
+		}
+	}
+}
+
+// oscReleased tells button releases apart. Control surfaces send 1 when a
+// button is pressed and 0 when it is released, only the press is acted on.
+func oscReleased(message *osc.Message) bool {
+	if len(message.Arguments) == 0 || message.Address == "/fox/arm" {
+		return false
+	}
+
+	if _, isString := message.Arguments[0].(string); isString {
+		return false
+	}
+
+	value, ok := message.Float(0)
+
+	return ok && value == 0
+}
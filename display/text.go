@@ -0,0 +1,370 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"fox-audio/model"
+	"fox-audio/util"
+
+	"code.rocketnine.space/tslocum/cview"
+)
+
+const (
+	textTimeFormat     = "2006-01-02 15:04:05"
+	textStatusInterval = 5 * time.Second
+)
+
+//
+// types
+//
+
+// TextUI prints plain status lines and log messages without any cursor
+// control, for terminals that can't hold a full screen UI
+type TextUI struct {
+	shutdownChannel chan bool
+	loopDone        chan bool
+
+	output *os.File
+
+	statusTransport Status
+	printedStatus   Status
+
+	statusDuration    float64
+	statusFormat      string
+	statusSessionSize uint64
+	statusErrorCount  int
+	statusProfileName string
+	statusTakeName    string
+	statusDirectory   string
+	printedTakeName   string
+
+	metricDiskUsedPct        int
+	metricBufferUsedPct      int
+	metricCycleBufferUsedPct int
+	metricAudioLoadPct       int
+	metricDiskLoadPct        int
+
+	signalLevels []model.SignalLevel
+	clipCounts   []uint64
+	portNames    map[int]string
+	loudness     []model.LoudnessLevel
+}
+
+//
+// constructor
+//
+
+func NewTextUI(output *os.File) *TextUI {
+	textUi := &TextUI{
+		shutdownChannel: make(chan bool, 1),
+		loopDone:        make(chan bool),
+
+		output: output,
+
+		statusTransport: StatusStarting,
+		printedStatus:   StatusStarting,
+
+		signalLevels: make([]model.SignalLevel, 0),
+		clipCounts:   make([]uint64, 0),
+		portNames:    make(map[int]string),
+		loudness:     make([]model.LoudnessLevel, 0),
+	}
+
+	return textUi
+}
+
+func (t *TextUI) Initalize() {
+	// nothing to do here
+}
+
+func (t *TextUI) Start() {
+	go t.excecuteLoop()
+}
+
+func (t *TextUI) excecuteLoop() {
+	defer t.HandlePanic()
+
+	slog.Debug("Text loop started")
+
+	lastStatus := time.Now()
+
+	for {
+		if len(t.shutdownChannel) > 0 {
+			break
+		}
+
+		// a new take or a change in the transport is printed right away
+		if t.statusTakeName != t.printedTakeName {
+			t.printedTakeName = t.statusTakeName
+			t.printTake()
+		}
+
+		if t.statusTransport != t.printedStatus || time.Since(lastStatus) >= textStatusInterval {
+			t.printedStatus = t.statusTransport
+			lastStatus = time.Now()
+
+			t.printStatus()
+		}
+
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	close(t.loopDone)
+}
+
+func (t *TextUI) Shutdown() {
+	slog.Debug("Shutting down text UI")
+	t.shutdownChannel <- true
+
+	slog.Debug("Waiting for text UI to shut down")
+	t.WaitForShutdown()
+}
+
+func (t *TextUI) IsShutdown() bool {
+	return len(t.shutdownChannel) > 0
+}
+
+func (t *TextUI) WaitForShutdown() {
+	<-t.loopDone
+}
+
+func (t *TextUI) SetTransportStatus(status Status) {
+	t.statusTransport = status
+}
+
+func (t *TextUI) SetDuration(duration float64) {
+	t.statusDuration = duration
+}
+
+func (t *TextUI) SetAudioFormat(format string) {
+	t.statusFormat = format
+}
+
+func (t *TextUI) SetProfileName(value string) {
+	t.statusProfileName = value
+}
+
+func (t *TextUI) SetTakeName(value string) {
+	t.statusTakeName = value
+}
+
+func (t *TextUI) SetDirectory(value string) {
+	t.statusDirectory = value
+}
+
+func (t *TextUI) SetSessionSize(size uint64) {
+	t.statusSessionSize = size
+}
+
+func (t *TextUI) IncrementErrorCount() {
+	t.statusErrorCount += 1
+}
+
+func (t *TextUI) UpdateSignalLevels(levels []model.SignalLevel) {
+	copy(t.signalLevels, levels)
+}
+
+func (t *TextUI) SetChannelArmStatus(channel int, armed bool) {
+	// arming is already logged
+}
+
+func (t *TextUI) SetOutputFiles(outputFiles []model.UiOutputFile) {
+	// nothing to do here
+}
+
+func (t *TextUI) UpdateOutputFileSizes(sizes []uint64) {
+	// the session size is enough here
+}
+
+func (t *TextUI) UpdateOutputFileHealth(health []model.FileHealth) {
+	// file failures are already logged
+}
+
+func (t *TextUI) SetTheme(options *model.ThemeOptions) error {
+	// nothing to do here
+	return nil
+}
+
+func (t *TextUI) SetMeterStandard(options *model.MeteringOptions) {
+	// nothing to do here
+}
+
+func (t *TextUI) SetChannelCount(channelCount int) {
+	t.signalLevels = make([]model.SignalLevel, channelCount)
+	t.clipCounts = make([]uint64, channelCount)
+}
+
+func (t *TextUI) SetChannelGroups(groups []model.UiChannelGroup) {
+	t.portNames = make(map[int]string)
+
+	for _, group := range groups {
+		for _, port := range group.Ports {
+			t.portNames[port] = group.Name
+		}
+	}
+}
+
+func (t *TextUI) UpdateStereoLevels(levels []model.StereoLevel) {
+	// nothing to do here
+}
+
+func (t *TextUI) SetLoudnessMeters(names []string) {
+	t.loudness = make([]model.LoudnessLevel, len(names))
+
+	for i, name := range names {
+		t.loudness[i].Name = name
+	}
+}
+
+func (t *TextUI) UpdateLoudness(levels []model.LoudnessLevel) {
+	t.loudness = levels
+}
+
+func (t *TextUI) UpdateSpectrum(spectrum *model.Spectrum) {
+	// the spectrum is only shown in the TUI
+}
+
+func (t *TextUI) SetActionHandler(handler ActionHandler) {
+	// nothing to do here
+}
+
+func (t *TextUI) WriteLevelLog(level slog.Level, message string) {
+	t.printLine(fmt.Sprintf("%-5s %s", level.String(), message))
+}
+
+func (t *TextUI) SetAudioLoad(percent int) {
+	t.metricAudioLoadPct = percent
+}
+
+func (t *TextUI) SetDiskUsage(percent int) {
+	t.metricDiskUsedPct = percent
+}
+
+func (t *TextUI) SetBufferUtilization(percent int) {
+	t.metricBufferUsedPct = percent
+}
+
+func (t *TextUI) SetDiskLoad(percent int) {
+	t.metricDiskLoadPct = percent
+}
+
+func (t *TextUI) SetCycleBuffer(percent int) {
+	t.metricCycleBufferUsedPct = percent
+}
+
+func (t *TextUI) ShowSummary(summary *model.SessionSummary) {
+	text := string(cview.StripTags([]byte(formatSummary(summary)), true, false))
+
+	fmt.Fprintln(t.output)
+	fmt.Fprintln(t.output, text)
+}
+
+func (t *TextUI) HandlePanic() {
+	// nothing to do here
+}
+
+//
+// private functions
+//
+
+func (t *TextUI) printLine(line string) {
+	fmt.Fprintln(t.output, time.Now().Format(textTimeFormat)+" "+line)
+}
+
+func (t *TextUI) printTake() {
+	t.printLine(fmt.Sprintf("TAKE  %s  profile %s  %s  %s", t.statusTakeName, t.statusProfileName, t.statusFormat, t.statusDirectory))
+}
+
+func (t *TextUI) printStatus() {
+	t.printLine(fmt.Sprintf("%-5s %s  %s  %s  errors %d  disk %d%%  disk load %d%%  audio load %d%%  buffer %d%%",
+		"STAT",
+		statusNames[t.statusTransport],
+		util.FormatDuration(t.statusDuration),
+		util.FormatSize(t.statusSessionSize),
+		t.statusErrorCount,
+		t.metricDiskUsedPct,
+		t.metricDiskLoadPct,
+		t.metricAudioLoadPct,
+		t.metricBufferUsedPct))
+
+	if levels := t.formatLevels(); levels != "" {
+		t.printLine(fmt.Sprintf("%-5s %s", "LEVEL", levels))
+	}
+
+	for _, level := range t.loudness {
+		t.printLine(fmt.Sprintf("%-5s %s", "LOUD", formatTextLoudness(level)))
+	}
+}
+
+// formatLevels names the loudest input and any inputs that clipped since the
+// last status line
+func (t *TextUI) formatLevels() string {
+	if len(t.signalLevels) == 0 {
+		return ""
+	}
+
+	loudest := 0
+	clipped := make([]string, 0)
+
+	for i, level := range t.signalLevels {
+		if level.Peak > t.signalLevels[loudest].Peak {
+			loudest = i
+		}
+
+		if i < len(t.clipCounts) && level.ClipCount > t.clipCounts[i] {
+			clipped = append(clipped, t.portName(i))
+			t.clipCounts[i] = level.ClipCount
+		}
+	}
+
+	levels := "peak " + strings.TrimSpace(loudnessValue(t.signalLevels[loudest].Peak, "%.1f")) + " dBFS on " + t.portName(loudest)
+
+	if len(clipped) > 0 {
+		levels += "  CLIPPED " + strings.Join(clipped, ", ")
+	}
+
+	return levels
+}
+
+func (t *TextUI) portName(port int) string {
+	if name := t.portNames[port]; name != "" {
+		return fmt.Sprintf("%d (%s)", port+1, name)
+	}
+
+	return fmt.Sprintf("%d", port+1)
+}
+
+func formatTextLoudness(level model.LoudnessLevel) string {
+	return fmt.Sprintf("%s  M %s  S %s  I %s LUFS  TP %s dBTP",
+		level.Name,
+		strings.TrimSpace(loudnessValue(level.Momentary, "%.1f")),
+		strings.TrimSpace(loudnessValue(level.ShortTerm, "%.1f")),
+		strings.TrimSpace(loudnessValue(level.Integrated, "%.1f")),
+		strings.TrimSpace(loudnessValue(level.TruePeak, "%.1f")))
+}
@@ -0,0 +1,292 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"fox-audio/model"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Palette holds the colors of the TUI by the role they play, so a palette can
+// swap hues without changing what they mean
+type Palette struct {
+	Name string
+
+	Border tcell.Color
+	Track  tcell.Color // empty part of status meters and indicators
+	Muted  tcell.Color
+
+	MeterLow                 tcell.Color
+	MeterNormal              tcell.Color
+	MeterWarn                tcell.Color
+	MeterHigh                tcell.Color
+	MeterClip                tcell.Color
+	MeterDisarmed            tcell.Color
+	MeterAlternateBackground tcell.Color
+
+	StatusOk    tcell.Color
+	StatusWarn  tcell.Color
+	StatusError tcell.Color
+	StatusInfo  tcell.Color
+	Recording   tcell.Color
+
+	LogWarn  tcell.Color
+	LogError tcell.Color
+	LogDebug tcell.Color
+}
+
+const (
+	PaletteDefault      = "default"
+	PaletteHighContrast = "high_contrast"
+	PaletteColorBlind   = "colorblind"
+	PaletteNight        = "night"
+)
+
+var (
+	builtinPalettes = []Palette{
+		{
+			Name:                     PaletteDefault,
+			Border:                   tcell.Color243,
+			Track:                    tcell.Color242,
+			Muted:                    tcell.ColorGray,
+			MeterLow:                 tcell.Color72,
+			MeterNormal:              tcell.Color71,
+			MeterWarn:                tcell.Color142,
+			MeterHigh:                tcell.Color131,
+			MeterClip:                tcell.Color124,
+			MeterDisarmed:            tcell.Color242,
+			MeterAlternateBackground: tcell.Color233,
+			StatusOk:                 tcell.Color71,
+			StatusWarn:               tcell.Color142,
+			StatusError:              tcell.Color124,
+			StatusInfo:               tcell.ColorBlue,
+			Recording:                tcell.Color124,
+			LogWarn:                  tcell.Color142,
+			LogError:                 tcell.Color124,
+			LogDebug:                 tcell.ColorGray,
+		},
+		{
+			Name:                     PaletteHighContrast,
+			Border:                   tcell.ColorWhite,
+			Track:                    tcell.Color245,
+			Muted:                    tcell.ColorSilver,
+			MeterLow:                 tcell.ColorLime,
+			MeterNormal:              tcell.ColorLime,
+			MeterWarn:                tcell.ColorYellow,
+			MeterHigh:                tcell.ColorOrange,
+			MeterClip:                tcell.ColorRed,
+			MeterDisarmed:            tcell.ColorGray,
+			MeterAlternateBackground: tcell.Color236,
+			StatusOk:                 tcell.ColorLime,
+			StatusWarn:               tcell.ColorYellow,
+			StatusError:              tcell.ColorRed,
+			StatusInfo:               tcell.ColorAqua,
+			Recording:                tcell.ColorRed,
+			LogWarn:                  tcell.ColorYellow,
+			LogError:                 tcell.ColorRed,
+			LogDebug:                 tcell.ColorSilver,
+		},
+		{
+			// Okabe-Ito colors, distinguishable with the common forms of color blindness
+			Name:                     PaletteColorBlind,
+			Border:                   tcell.Color243,
+			Track:                    tcell.Color242,
+			Muted:                    tcell.ColorGray,
+			MeterLow:                 tcell.NewHexColor(0x0072B2),
+			MeterNormal:              tcell.NewHexColor(0x56B4E9),
+			MeterWarn:                tcell.NewHexColor(0xF0E442),
+			MeterHigh:                tcell.NewHexColor(0xE69F00),
+			MeterClip:                tcell.NewHexColor(0xD55E00),
+			MeterDisarmed:            tcell.Color242,
+			MeterAlternateBackground: tcell.Color233,
+			StatusOk:                 tcell.NewHexColor(0x56B4E9),
+			StatusWarn:               tcell.NewHexColor(0xE69F00),
+			StatusError:              tcell.NewHexColor(0xD55E00),
+			StatusInfo:               tcell.NewHexColor(0x0072B2),
+			Recording:                tcell.NewHexColor(0xD55E00),
+			LogWarn:                  tcell.NewHexColor(0xE69F00),
+			LogError:                 tcell.NewHexColor(0xD55E00),
+			LogDebug:                 tcell.ColorGray,
+		},
+		{
+			// low brightness for dark rooms
+			Name:                     PaletteNight,
+			Border:                   tcell.Color237,
+			Track:                    tcell.Color236,
+			Muted:                    tcell.Color240,
+			MeterLow:                 tcell.Color23,
+			MeterNormal:              tcell.Color28,
+			MeterWarn:                tcell.Color100,
+			MeterHigh:                tcell.Color94,
+			MeterClip:                tcell.Color88,
+			MeterDisarmed:            tcell.Color238,
+			MeterAlternateBackground: tcell.Color233,
+			StatusOk:                 tcell.Color28,
+			StatusWarn:               tcell.Color100,
+			StatusError:              tcell.Color88,
+			StatusInfo:               tcell.Color24,
+			Recording:                tcell.Color88,
+			LogWarn:                  tcell.Color100,
+			LogError:                 tcell.Color88,
+			LogDebug:                 tcell.Color240,
+		},
+	}
+
+	current atomic.Pointer[Palette]
+)
+
+func init() {
+	current.Store(&builtinPalettes[0])
+}
+
+// Current returns the palette in use
+func Current() *Palette {
+	return current.Load()
+}
+
+// SetCurrent switches the palette, widgets pick it up the next time they draw
+func SetCurrent(palette *Palette) {
+	current.Store(palette)
+}
+
+// Tag returns the color as a cview color tag value
+func Tag(color tcell.Color) string {
+	return fmt.Sprintf("#%06x", color.Hex())
+}
+
+// LoadPalettes returns the built-in palettes followed by the custom palettes
+// from the config, sorted by name
+func LoadPalettes(options *model.ThemeOptions) ([]*Palette, error) {
+	palettes := make([]*Palette, 0, len(builtinPalettes)+len(options.Palettes))
+
+	for i := range builtinPalettes {
+		palette := builtinPalettes[i]
+		palettes = append(palettes, &palette)
+	}
+
+	names := make([]string, 0, len(options.Palettes))
+	for name := range options.Palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		palette, err := newCustomPalette(name, options.Palettes[name])
+		if err != nil {
+			return nil, err
+		}
+
+		palettes = append(palettes, palette)
+	}
+
+	return palettes, nil
+}
+
+func newCustomPalette(name string, custom model.ThemePalette) (*Palette, error) {
+	if slices.ContainsFunc(builtinPalettes, func(p Palette) bool { return p.Name == name }) {
+		return nil, errors.New("theme palette " + name + " has the same name as a built-in palette")
+	}
+
+	base := custom.Base
+	if base == "" {
+		base = PaletteDefault
+	}
+
+	index := slices.IndexFunc(builtinPalettes, func(p Palette) bool { return p.Name == base })
+	if index < 0 {
+		return nil, errors.New("invalid base for theme palette " + name + ": " + base + ". Valid options: " + strings.Join(BuiltinNames(), ", "))
+	}
+
+	palette := builtinPalettes[index]
+	palette.Name = name
+	roles := palette.roles()
+
+	for role, value := range custom.Colors {
+		color, found := roles[role]
+		if !found {
+			return nil, errors.New("invalid color role in theme palette " + name + ": " + role + ". Valid options: " + strings.Join(RoleNames(), ", "))
+		}
+
+		parsed := tcell.GetColor(value)
+		if parsed == tcell.ColorDefault && value != "default" {
+			return nil, errors.New("invalid color in theme palette " + name + " for " + role + ": " + value)
+		}
+
+		*color = parsed
+	}
+
+	return &palette, nil
+}
+
+// roles maps the names used in the config to the colors of the palette
+func (p *Palette) roles() map[string]*tcell.Color {
+	return map[string]*tcell.Color{
+		"border":                     &p.Border,
+		"track":                      &p.Track,
+		"muted":                      &p.Muted,
+		"meter_low":                  &p.MeterLow,
+		"meter_normal":               &p.MeterNormal,
+		"meter_warn":                 &p.MeterWarn,
+		"meter_high":                 &p.MeterHigh,
+		"meter_clip":                 &p.MeterClip,
+		"meter_disarmed":             &p.MeterDisarmed,
+		"meter_alternate_background": &p.MeterAlternateBackground,
+		"status_ok":                  &p.StatusOk,
+		"status_warn":                &p.StatusWarn,
+		"status_error":               &p.StatusError,
+		"status_info":                &p.StatusInfo,
+		"recording":                  &p.Recording,
+		"log_warn":                   &p.LogWarn,
+		"log_error":                  &p.LogError,
+		"log_debug":                  &p.LogDebug,
+	}
+}
+
+func BuiltinNames() []string {
+	names := make([]string, len(builtinPalettes))
+
+	for i, palette := range builtinPalettes {
+		names[i] = palette.Name
+	}
+
+	return names
+}
+
+func RoleNames() []string {
+	names := make([]string, 0)
+
+	for name := range (&Palette{}).roles() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
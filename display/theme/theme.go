@@ -22,38 +22,6 @@
 // =================================================================================
 package theme
 
-import (
-	"github.com/gdamore/tcell/v2"
-)
-
-const (
-	Blue         = tcell.ColorBlue
-	BlueRGB      = "0000FF"
-	Green        = tcell.Color71
-	GreenRGB     = "5FAF5F"
-	Pink         = tcell.Color131
-	PinkRGB      = "AF5F5F"
-	Red          = tcell.Color124
-	RedRGB       = "AF0000"
-	SoftGreen    = tcell.Color72
-	SoftGreenRGB = "5FAF87"
-	Yellow       = tcell.Color142
-	YellowRGB    = "AFAF00"
-	Gray         = tcell.ColorGray
-	GrayRGB      = "808080"
-
-	BorderColor = tcell.Color243
-
-	LevelMeterAlternateBackgroundColor = tcell.Color233
-	LevelMeterDisarmdFillColor         = tcell.Color242
-)
-
-// 0:    theme.Red, // 124?
-// -2:   tcell.Color131, // 124?
-// -6:   tcell.Color142, // 131?
-// -18:  tcell.Color71,  // 142? 65? muted 71?
-// -150: tcell.Color72,  //tcell.Color120, 59? 60? 61? 66? 67? 68? 72?
-
 const (
 	RuneClock       = rune(9201) // ⏱
 	RunePause       = rune(9208) // ⏸
@@ -25,6 +25,9 @@ package display
 import (
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"fox-audio/display/custom"
@@ -52,6 +55,17 @@ const (
 	layoutOutputFileColumnWidth = 45
 	layoutOutputFilePortsWidth  = 8
 	layoutOutputFileSizeWidth   = 11
+
+	layoutStatusRowCount = 10
+
+	layoutLoudnessNameWidth = 16
+
+	layoutSummaryNameWidth = 24
+	layoutSummarySizeWidth = 11
+	layoutSummaryPeakWidth = 10
+
+	panelMain    = "main"
+	panelSummary = "summary"
 )
 
 //
@@ -59,18 +73,8 @@ const (
 //
 
 var (
-	meterSteps = []int{
-		0, -1, -2, -3, -4, -6, -8,
-		-10, -12, -15, -18, -21, -24, -27,
-		-30, -36, -42, -48, -54, -60}
-
-	levelColors = map[int]tcell.Color{
-		0:    theme.Red,
-		-2:   theme.Pink,
-		-6:   theme.Yellow,
-		-18:  theme.Green,
-		-150: theme.SoftGreen,
-	}
+	// EBU R128 maximum permitted true peak
+	truePeakLimit = -1.0
 )
 
 //
@@ -87,13 +91,27 @@ type Tui struct {
 	// armedChannelCount     int
 	// connectedChannelCount int
 
+	panels             *cview.Panels
 	gridApp            *cview.Grid
 	gridLevelMeters    *cview.Grid
 	gridOutputFiles    *cview.Grid
 	elementLevelMeters []*custom.LevelMeter
+	meterGroups        []*meterGroup
+	tvMeterSteps       *cview.TextView
+	meterOptions       *model.MeteringOptions
+	meterScale         *meterScale
+	meterScaleCompact  *meterScale
+	meterCompact       bool
+	meterNameLabels    bool
+	meterOffset        int
+	meterAreaWidth     int
 	elementOutputFiles []*custom.OutputFileField
 
+	gridLogs          *cview.Grid
 	tvLogs            *cview.TextView
+	tvLogStatus       *cview.TextView
+	inputLogSearch    *cview.InputField
+	tvLoudness        *cview.TextView
 	tvTransportStatus *custom.StatusText
 	tvPosition        *custom.StatusText
 	tvFormat          *custom.StatusText
@@ -108,6 +126,43 @@ type Tui struct {
 	statusMeterCycleBufferUsed *custom.StatusMeter
 	statusMeterAudioLoad       *custom.StatusMeter
 	statusMeterDiskLoad        *custom.StatusMeter
+
+	loudnessCount int
+
+	spectrumView  *custom.SpectrumView
+	spectrumShown bool
+	spectrumPort  int
+
+	actionHandler ActionHandler
+	commands      []*tuiCommand
+
+	tvHelp    *cview.TextView
+	gridHelp  *cview.Grid
+	helpShown bool
+	helpFocus cview.Primitive
+
+	inputCommand  *cview.InputField
+	tvCommandHint *cview.TextView
+	commandShown  bool
+	commandFocus  cview.Primitive
+
+	logEntries   []logEntry
+	logMutex     sync.Mutex
+	logFilter    int
+	logSearch    string
+	logFollow    bool
+	logBrowsing  bool
+	logSearching bool
+
+	directory string
+	takeName  string
+
+	palettes        []*theme.Palette
+	paletteIndex    int
+	transportStatus Status
+
+	summaryShown bool
+	summaryAck   chan bool
 }
 
 //
@@ -117,11 +172,16 @@ type Tui struct {
 func NewTui() *Tui {
 	tui := &Tui{
 		shutdownChannel:    make(chan bool, 1),
+		summaryAck:         make(chan bool, 1),
 		errorCount:         0,
 		elementLevelMeters: make([]*custom.LevelMeter, 0),
 		elementOutputFiles: make([]*custom.OutputFileField, 0),
+		meterNameLabels:    true,
+		commands:           newCommands(),
 	}
 
+	tui.SetMeterStandard(&model.MeteringOptions{Standard: model.MeterStandardDigital})
+
 	return tui
 }
 
@@ -133,11 +193,8 @@ func (tui *Tui) Initalize() {
 	tui.app = cview.NewApplication()
 	defer tui.HandlePanic()
 
-	meterRowHeight := len(meterSteps) + 2
-
-	statusRowCount := 10
-	statusRows := make([]int, statusRowCount)
-	for i := range statusRowCount {
+	statusRows := make([]int, layoutStatusRowCount)
+	for i := range layoutStatusRowCount {
 		statusRows[i] = 1
 	}
 
@@ -147,8 +204,7 @@ func (tui *Tui) Initalize() {
 	tui.gridApp.SetPadding(0, 0, 0, 0)
 	tui.gridApp.SetColumns(-1, layoutOutputFileColumnWidth)
 	tui.gridApp.SetBorders(true)
-	tui.gridApp.SetBordersColor(theme.BorderColor)
-	tui.gridApp.SetRows(statusRowCount, meterRowHeight, -1)
+	tui.gridApp.SetBordersColor(theme.Current().Border)
 	tui.gridApp.SetBackgroundColor(cview.Styles.PrimitiveBackgroundColor)
 
 	//
@@ -159,8 +215,6 @@ func (tui *Tui) Initalize() {
 	tui.gridOutputFiles.SetRows(-1)
 	tui.gridOutputFiles.SetBackgroundColor(cview.Styles.PrimitiveBackgroundColor)
 
-	tui.gridApp.AddItem(tui.gridOutputFiles, 0, 1, 3, 1, 0, 0, false)
-
 	//
 	// grid for the status meters
 	gridStatusMeters := cview.NewGrid()
@@ -171,7 +225,7 @@ func (tui *Tui) Initalize() {
 
 	// text status fields
 	tui.tvTransportStatus = custom.NewStatusTextField(layoutStatusItemHeaderWidth, "Status", string(theme.RuneRecord)+" Recording")
-	tui.tvTransportStatus.SetColor(theme.Red)
+	tui.tvTransportStatus.SetColor(theme.Current().Recording)
 	tui.tvPosition = custom.NewStatusTextField(layoutStatusItemHeaderWidth, "Position", "00:00:00.000")
 	tui.tvFormat = custom.NewStatusTextField(layoutStatusItemHeaderWidth, "Format", "Unknown")
 	tui.tvFileSize = custom.NewStatusTextField(layoutStatusItemHeaderWidth, "Session Size", "0 bytes")
@@ -187,7 +241,7 @@ func (tui *Tui) Initalize() {
 	gridStatusMeters.AddItem(tui.tvErrorCount.GetGrid(), 4, layoutStatusColumnIndex, 1, 1, 0, 0, false)
 	gridStatusMeters.AddItem(tui.tvProfileName.GetGrid(), 5, layoutStatusColumnIndex, 1, 1, 0, 0, false)
 	gridStatusMeters.AddItem(tui.tvTakeName.GetGrid(), 6, layoutStatusColumnIndex, 1, 1, 0, 0, false)
-	gridStatusMeters.AddItem(tui.tvDirectory.GetGrid(), statusRowCount-1, layoutStatusColumnIndex, 1, 2, 0, 0, false)
+	gridStatusMeters.AddItem(tui.tvDirectory.GetGrid(), layoutStatusRowCount-1, layoutStatusColumnIndex, 1, 2, 0, 0, false)
 
 	// progress bar status meters
 	tui.statusMeterDiskUsed = custom.NewStatusMeter(layoutStatusItemHeaderWidth, "Disk Space", 0, "%")
@@ -210,17 +264,31 @@ func (tui *Tui) Initalize() {
 	tui.gridLevelMeters.SetPadding(0, 0, 0, 0)
 	tui.gridLevelMeters.SetColumns(-1)
 
-	tui.gridApp.AddItem(tui.gridLevelMeters, 1, 0, 1, 1, 0, 0, false)
-
 	//
 	// grid for the log output view
-	tui.tvLogs = cview.NewTextView()
-	tui.tvLogs.SetPadding(0, 0, 0, 0)
-	tui.tvLogs.SetDynamicColors(true)
+	tui.initializeLogs()
 
-	tui.gridApp.AddItem(tui.tvLogs, 2, 0, 1, 1, 0, 0, true)
+	//
+	// loudness meters, only shown when the profile defines any
+	tui.tvLoudness = cview.NewTextView()
+	tui.tvLoudness.SetPadding(0, 0, 1, 1)
+	tui.tvLoudness.SetDynamicColors(true)
 
-	tui.app.SetRoot(tui.gridApp, true)
+	//
+	// spectrum of a single port, toggled from the keyboard
+	tui.spectrumView = newSpectrumView()
+
+	tui.layoutApp()
+
+	//
+	// panels allow overlays such as the session summary to be drawn over the main grid
+	tui.panels = cview.NewPanels()
+	tui.panels.AddPanel(panelMain, tui.gridApp, true, true)
+	tui.initializeHelp()
+	tui.initializeCommandPalette()
+
+	tui.app.SetRoot(tui.panels, true)
+	tui.app.SetAfterResizeFunc(tui.resized)
 }
 
 func (tui *Tui) Start() {
@@ -275,16 +343,68 @@ func (tui *Tui) HandlePanic() {
 
 func (tui *Tui) eventHandler(event *tcell.EventKey) *tcell.EventKey {
 	// Anything handled here will be executed on the main thread
-	switch event.Key() {
-	case tcell.KeyEsc:
-	case tcell.KeyCtrlC:
-		reaper.Reap()
+	if tui.summaryShown {
+		return tui.summaryEventHandler(event)
+	}
+
+	if tui.helpShown {
+		return tui.helpEventHandler(event)
+	}
+
+	// the search field and command palette get every key until they are done
+	if (tui.logSearching || tui.commandShown) && event.Key() != tcell.KeyCtrlC {
+		return event
+	}
+
+	if tui.logBrowsing {
+		return tui.logEventHandler(event)
+	}
+
+	if command := tui.commandForKey(event); command != nil {
+		tui.runCommand(command, nil)
 		return nil
 	}
 
 	return event
 }
 
+func (tui *Tui) clearClipIndicators() {
+	for _, meter := range tui.elementLevelMeters {
+		meter.ClearClip()
+	}
+}
+
+// layoutApp places the rows below the status area, leaving out optional
+// panels that have nothing to show
+func (tui *Tui) layoutApp() {
+	rows := []int{layoutStatusRowCount, tui.meterAreaHeight()}
+
+	tui.gridApp.RemoveItem(tui.gridLevelMeters)
+	tui.gridApp.RemoveItem(tui.tvLoudness)
+	tui.gridApp.RemoveItem(tui.spectrumView)
+	tui.gridApp.RemoveItem(tui.gridLogs)
+	tui.gridApp.RemoveItem(tui.gridOutputFiles)
+
+	tui.gridApp.AddItem(tui.gridLevelMeters, 1, 0, 1, 1, 0, 0, false)
+
+	if tui.loudnessCount > 0 {
+		tui.gridApp.AddItem(tui.tvLoudness, len(rows), 0, 1, 1, 0, 0, false)
+		rows = append(rows, tui.loudnessCount)
+	}
+
+	// the spectrum takes its rows from the logs so the meters keep their size
+	if tui.spectrumShown {
+		tui.gridApp.AddItem(tui.spectrumView, len(rows), 0, 1, 1, 0, 0, false)
+		rows = append(rows, layoutSpectrumRowProportion)
+	}
+
+	tui.gridApp.AddItem(tui.gridLogs, len(rows), 0, 1, 1, 0, 0, true)
+	rows = append(rows, -1)
+
+	tui.gridApp.AddItem(tui.gridOutputFiles, 0, 1, len(rows), 1, 0, 0, false)
+	tui.gridApp.SetRows(rows...)
+}
+
 func (tui *Tui) excecuteLoop() {
 	defer tui.HandlePanic()
 
@@ -308,11 +428,11 @@ func (tui *Tui) updateMeter(meter *custom.StatusMeter, value, warnPct, cautionPc
 	color := tcell.ColorDefault
 
 	if value <= warnPct {
-		color = theme.Green
+		color = theme.Current().StatusOk
 	} else if value <= cautionPct {
-		color = theme.Yellow
+		color = theme.Current().StatusWarn
 	} else {
-		color = theme.Red
+		color = theme.Current().StatusError
 	}
 
 	meter.SetCurrentValue(value)
@@ -325,31 +445,34 @@ func (tui *Tui) updateMeter(meter *custom.StatusMeter, value, warnPct, cautionPc
 
 func (tui *Tui) SetTransportStatus(status Status) {
 	if status < 0 || status > 5 {
-		panic("invalid status value provided: " + string(status))
+		panic("invalid status value provided: " + fmt.Sprint(int(status)))
 	}
 
 	var icon rune
 	var color tcell.Color
 	var transportStatus string
 
+	palette := theme.Current()
+	tui.transportStatus = status
+
 	if status == StatusPaused {
 		icon = theme.RunePause
-		color = theme.Blue
+		color = palette.StatusInfo
 	} else if status == StatusPlaying {
 		icon = theme.RunePlay
-		color = theme.Green
+		color = palette.StatusOk
 	} else if status == StatusRecording {
 		icon = theme.RuneRecord
-		color = theme.Red
+		color = palette.Recording
 	} else if status == StatusStarting {
 		icon = theme.RuneClock
-		color = theme.Yellow
+		color = palette.StatusWarn
 	} else if status == StatusShuttingDown {
 		icon = theme.RuneClock
-		color = theme.Yellow
+		color = palette.StatusWarn
 	} else if status == StatusFailed {
 		icon = theme.RuneFailed
-		color = theme.Red
+		color = palette.StatusError
 	}
 
 	transportStatus = statusNames[status]
@@ -371,10 +494,12 @@ func (tui *Tui) SetProfileName(value string) {
 }
 
 func (tui *Tui) SetTakeName(value string) {
+	tui.takeName = value
 	tui.tvTakeName.SetCurrentValue(value)
 }
 
 func (tui *Tui) SetDirectory(value string) {
+	tui.directory = value
 	tui.tvDirectory.SetCurrentValue(value)
 }
 
@@ -387,7 +512,7 @@ func (tui *Tui) IncrementErrorCount() {
 	tui.tvErrorCount.SetCurrentValue(fmt.Sprintf("%d", tui.errorCount))
 
 	if tui.errorCount > 0 {
-		tui.tvErrorCount.SetColor(theme.Red)
+		tui.tvErrorCount.SetColor(theme.Current().StatusError)
 	}
 }
 
@@ -398,17 +523,36 @@ func (tui *Tui) IncrementErrorCount() {
 func (tui *Tui) UpdateSignalLevels(levels []model.SignalLevel) {
 	for i := range levels {
 		level := levels[i]
-		tui.elementLevelMeters[i].SetLevel(level.Instant)
+		body := level.Meter
+		if tui.meterScale.rmsBody {
+			body = level.Instant
+		}
+
+		tui.elementLevelMeters[i].SetLevels(body, level.Meter, level.Peak)
+
+		if level.Clipped {
+			tui.elementLevelMeters[i].SetClipped()
+		}
 	}
 }
 
 func (tui *Tui) SetChannelArmStatus(channel int, armed bool) {
 	tui.elementLevelMeters[channel].ArmChannel(armed)
+
+	// the strip label is dimmed when none of its ports are armed
+	meterWidth, _ := tui.meterBanks()
+	for _, group := range tui.meterGroups {
+		if slices.Contains(group.ports, channel) {
+			tui.labelMeterGroup(group, meterWidth)
+		}
+	}
 }
 
+// SetOutputFiles replaces the file list. A new take calls this from the disk
+// writer, so the list is only changed and read on the UI goroutine.
 func (tui *Tui) SetOutputFiles(outputFiles []model.UiOutputFile) {
 	fileCount := len(outputFiles)
-	tui.elementOutputFiles = make([]*custom.OutputFileField, fileCount)
+	elementOutputFiles := make([]*custom.OutputFileField, fileCount)
 
 	outputFileRows := make([]int, fileCount+1)
 	for i := range fileCount {
@@ -416,78 +560,110 @@ func (tui *Tui) SetOutputFiles(outputFiles []model.UiOutputFile) {
 	}
 	outputFileRows[fileCount] = -1
 
-	tui.gridOutputFiles.SetRows(outputFileRows...)
-
 	// loop through and create a new output file ui item for each output file
 	for i, outputFile := range outputFiles {
-		outputFileField := custom.NewOutputFileField(layoutOutputFilePortsWidth, layoutOutputFileSizeWidth, outputFile)
-		tui.elementOutputFiles[i] = outputFileField
-		tui.gridOutputFiles.AddItem(outputFileField.GetGrid(), i, 0, 1, 1, 0, 0, false)
+		elementOutputFiles[i] = custom.NewOutputFileField(layoutOutputFilePortsWidth, layoutOutputFileSizeWidth, outputFile)
+	}
+
+	if tui.IsShutdown() {
+		return
 	}
+
+	tui.app.QueueUpdateDraw(func() {
+		// a new take replaces the files of the previous one
+		tui.elementOutputFiles = elementOutputFiles
+		tui.gridOutputFiles.Clear()
+		tui.gridOutputFiles.SetRows(outputFileRows...)
+
+		for i, outputFileField := range elementOutputFiles {
+			tui.gridOutputFiles.AddItem(outputFileField.GetGrid(), i, 0, 1, 1, 0, 0, false)
+		}
+	})
 }
 
 func (tui *Tui) UpdateOutputFileSizes(sizes []uint64) {
-	for i, size := range sizes {
-		if len(tui.elementOutputFiles) > i {
-			tui.elementOutputFiles[i].SetSize(size)
+	if tui.IsShutdown() {
+		return
+	}
+
+	tui.app.QueueUpdate(func() {
+		for i, size := range sizes {
+			if len(tui.elementOutputFiles) > i {
+				tui.elementOutputFiles[i].SetSize(size)
+			}
 		}
+	})
+}
+
+func (tui *Tui) UpdateOutputFileHealth(health []model.FileHealth) {
+	if tui.IsShutdown() {
+		return
 	}
+
+	tui.app.QueueUpdate(func() {
+		for i, state := range health {
+			if len(tui.elementOutputFiles) > i {
+				tui.elementOutputFiles[i].SetHealth(state)
+			}
+		}
+	})
+}
+
+// SetMeterStandard selects the scale and colors of the level meters. It must
+// be called before Initalize.
+func (tui *Tui) SetMeterStandard(options *model.MeteringOptions) {
+	tui.meterOptions = options
+	tui.meterScale = newMeterScale(options)
+	tui.meterScaleCompact = tui.meterScale.compact()
 }
 
 func (tui *Tui) SetChannelCount(channelCount int) {
 	tui.elementLevelMeters = make([]*custom.LevelMeter, channelCount)
 
-	levelColumns := make([]int, channelCount+2)
-	levelColumns[0] = 5
-	for i := range channelCount {
-		levelColumns[i+1] = layoutMeterWidth
-	}
-	levelColumns[channelCount+1] = -1
-
-	tui.gridLevelMeters.SetColumns(levelColumns...)
-
-	meterStepLabel := cview.NewTextView()
-	meterStepLabel.SetPadding(0, 0, 0, 0)
+	tui.tvMeterSteps = cview.NewTextView()
+	tui.tvMeterSteps.SetPadding(0, 0, 0, 0)
+	tui.writeMeterSteps()
 
-	meterStepLabel.Write([]byte(fmt.Sprintln()))
-	for step := 0; step < len(meterSteps); step++ {
-		meterStepLabel.Write([]byte(fmt.Sprintf("%3v\n", fmt.Sprintf("%d", meterSteps[step]))))
-	}
-	tui.gridLevelMeters.AddItem(meterStepLabel, 0, 0, 1, 1, 0, 0, false)
+	scale := tui.currentMeterScale()
 
 	for i := range channelCount {
-		tui.elementLevelMeters[i] = custom.NewLevelMeter(meterSteps, levelColors)
+		tui.elementLevelMeters[i] = custom.NewLevelMeter(scale.steps, scale.colors)
 		tui.elementLevelMeters[i].SetBorder(false)
 		tui.elementLevelMeters[i].SetPadding(0, 0, 1, 1)
 		tui.elementLevelMeters[i].SetMinLevel(-150)
-		tui.elementLevelMeters[i].SetLevel(-99)
+		tui.elementLevelMeters[i].SetLevels(-150, -150, -150)
 		tui.elementLevelMeters[i].SetChannelNumber(fmt.Sprintf("%d", i+1))
 		tui.elementLevelMeters[i].ArmChannel(false)
-
-		if i%2 == 1 {
-			tui.elementLevelMeters[i].SetBackgroundColor(theme.LevelMeterAlternateBackgroundColor)
-		}
-
-		tui.gridLevelMeters.AddItem(tui.elementLevelMeters[i], 0, i+1, 1, 1, 0, 0, false)
 	}
+
+	// every port is its own strip until the channel groups are known
+	tui.SetChannelGroups(nil)
 }
 
 //
-// logging
+// loudness
 //
 
-func (tui *Tui) WriteLevelLog(level slog.Level, message string) {
-	color := "-"
+func (tui *Tui) SetLoudnessMeters(names []string) {
+	tui.loudnessCount = len(names)
+	tui.layoutApp()
+
+	levels := make([]model.LoudnessLevel, len(names))
+	for i, name := range names {
+		levels[i] = model.LoudnessLevel{Name: name, Momentary: -150, ShortTerm: -150, Integrated: -150, TruePeak: -150}
+	}
+
+	tui.UpdateLoudness(levels)
+}
+
+func (tui *Tui) UpdateLoudness(levels []model.LoudnessLevel) {
+	lines := make([]string, len(levels))
 
-	if level == slog.LevelWarn {
-		color = "#" + theme.YellowRGB // "yellow"
-	} else if level == slog.LevelError {
-		color = "#" + theme.RedRGB + "::b"
-	} else if level == slog.LevelDebug {
-		color = "#" + theme.GrayRGB
+	for i, level := range levels {
+		lines[i] = formatLoudness(level)
 	}
 
-	tui.tvLogs.Write([]byte(fmt.Sprintf("[%s][%s[] [%s[] %s[-:-:-]\n", color, time.Now().Format("2006-01-02 15:04:05"), level.String(), message)))
+	tui.tvLoudness.SetText(strings.Join(lines, "\n"))
 }
 
 //
@@ -0,0 +1,422 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"fox-audio/display/theme"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	layoutHelpWidth     = 88
+	layoutCommandWidth  = 64
+	layoutCommandHeight = 10
+
+	panelHelp    = "help"
+	panelCommand = "command"
+)
+
+// tuiCommand is something the operator can do, either from its keys or by
+// typing its name in the command palette
+type tuiCommand struct {
+	name        string
+	args        string
+	runes       []rune
+	keys        []tcell.Key
+	description string
+	run         func(tui *Tui, args []string) error
+}
+
+// keys that are only handled while browsing the logs
+var logKeyHelp = [][2]string{
+	{"1-4", "Show errors, warnings, info or debug and up"},
+	{"/", "Search, enter keeps the search and esc clears it"},
+	{"f", "Pause or resume following new entries"},
+	{"End/G", "Jump to the newest entry and follow"},
+	{"w", "Save the log next to the take"},
+	{"Esc/Tab", "Leave the log browser"},
+}
+
+// newCommands builds the command table shared by the key bindings, the help
+// overlay and the command palette
+func newCommands() []*tuiCommand {
+	// wraps a display only function that can't fail
+	local := func(fn func(tui *Tui)) func(tui *Tui, args []string) error {
+		return func(tui *Tui, args []string) error {
+			fn(tui)
+			return nil
+		}
+	}
+
+	// hands the command and its arguments to the app
+	action := func(action Action) func(tui *Tui, args []string) error {
+		return func(tui *Tui, args []string) error {
+			return tui.requestAction(action, args...)
+		}
+	}
+
+	return []*tuiCommand{
+		{name: "help", runes: []rune{'?'}, keys: []tcell.Key{tcell.KeyF1}, description: "Show this help", run: local((*Tui).showHelp)},
+		{name: "palette", runes: []rune{':'}, description: "Open the command palette", run: local((*Tui).showCommandPalette)},
+
+		{name: "record", description: "Resume recording", run: action(ActionRecord)},
+		{name: "pause", description: "Pause recording, the files are kept open", run: action(ActionPause)},
+		{name: "stop", keys: []tcell.Key{tcell.KeyCtrlC}, description: "Stop recording and exit", run: action(ActionStop)},
+		{name: "new take", description: "Finish the current take and start the next", run: action(ActionNewTake)},
+		{name: "arm", args: "<input|channel>", description: "Arm a channel", run: action(ActionArm)},
+		{name: "disarm", args: "<input|channel>", description: "Disarm a channel, it is recorded as silence", run: action(ActionDisarm)},
+		{name: "marker", args: "[name]", runes: []rune{'m'}, description: "Mark the current position in the take", run: action(ActionMarker)},
+
+		{name: "clear clips", runes: []rune{'c'}, description: "Clear the clip indicators", run: local((*Tui).clearClipIndicators)},
+		{name: "page prev", runes: []rune{'<'}, description: "Previous bank of meters", run: local(func(tui *Tui) { tui.pageMeters(-1) })},
+		{name: "page next", runes: []rune{'>'}, description: "Next bank of meters", run: local(func(tui *Tui) { tui.pageMeters(1) })},
+		{name: "scroll left", runes: []rune{','}, description: "Scroll the meters by one strip", run: local(func(tui *Tui) { tui.scrollMeters(-1) })},
+		{name: "scroll right", runes: []rune{'.'}, description: "Scroll the meters by one strip", run: local(func(tui *Tui) { tui.scrollMeters(1) })},
+		{name: "compact", runes: []rune{'z'}, description: "Toggle narrow meters", run: local((*Tui).toggleCompactMeters)},
+		{name: "labels", runes: []rune{'n'}, description: "Toggle channel names on the meters", run: local((*Tui).toggleMeterLabels)},
+		{name: "spectrum", runes: []rune{'s'}, description: "Show or hide the spectrum analyzer", run: local((*Tui).toggleSpectrum)},
+		{name: "spectrum prev", runes: []rune{'['}, description: "Analyze the previous input", run: local(func(tui *Tui) { tui.selectSpectrumPort(-1) })},
+		{name: "spectrum next", runes: []rune{']'}, description: "Analyze the next input", run: local(func(tui *Tui) { tui.selectSpectrumPort(1) })},
+		{name: "theme", runes: []rune{'t'}, description: "Switch to the next theme", run: local((*Tui).cycleTheme)},
+		{name: "logs", runes: []rune{'l'}, description: "Browse the logs", run: local((*Tui).browseLogs)},
+	}
+}
+
+// commandForKey returns the command bound to a key, if any
+func (tui *Tui) commandForKey(event *tcell.EventKey) *tuiCommand {
+	for _, command := range tui.commands {
+		if event.Key() == tcell.KeyRune {
+			for _, r := range command.runes {
+				if r == event.Rune() {
+					return command
+				}
+			}
+
+			continue
+		}
+
+		for _, key := range command.keys {
+			if key == event.Key() {
+				return command
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseCommand finds the command with the longest name matching the start of
+// the text, the remaining words are its arguments
+func (tui *Tui) parseCommand(text string) (*tuiCommand, []string, error) {
+	fields := strings.Fields(text)
+
+	var found *tuiCommand
+	foundLength := 0
+
+	for _, command := range tui.commands {
+		name := strings.Fields(command.name)
+
+		if len(name) <= foundLength || len(name) > len(fields) {
+			continue
+		}
+
+		matches := true
+		for i, word := range name {
+			matches = matches && strings.EqualFold(word, fields[i])
+		}
+
+		if matches {
+			found = command
+			foundLength = len(name)
+		}
+	}
+
+	if found == nil {
+		if len(fields) == 0 {
+			return nil, nil, errors.New("no command entered")
+		}
+
+		return nil, nil, errors.New("unknown command: " + fields[0])
+	}
+
+	args := fields[foundLength:]
+
+	if found.args == "" && len(args) > 0 {
+		return nil, nil, errors.New(found.name + " does not take any arguments")
+	}
+
+	if strings.HasPrefix(found.args, "<") && len(args) == 0 {
+		return nil, nil, errors.New("usage: " + found.name + " " + found.args)
+	}
+
+	return found, args, nil
+}
+
+func (tui *Tui) runCommand(command *tuiCommand, args []string) {
+	if err := command.run(tui, args); err != nil {
+		slog.Warn(command.name + ": " + err.Error())
+	}
+}
+
+// requestAction hands an action to the app and returns its result
+func (tui *Tui) requestAction(action Action, args ...string) error {
+	if tui.actionHandler == nil {
+		return errors.New("not available")
+	}
+
+	return tui.actionHandler(action, args)
+}
+
+// runAction hands an action to the app, logging any failure
+func (tui *Tui) runAction(action Action, args ...string) {
+	if err := tui.requestAction(action, args...); err != nil && tui.actionHandler != nil {
+		slog.Error(err.Error())
+	}
+}
+
+//
+// help overlay
+//
+
+func (tui *Tui) initializeHelp() {
+	tui.tvHelp = cview.NewTextView()
+	tui.tvHelp.SetBorder(true)
+	tui.tvHelp.SetTitle(" Help ")
+	tui.tvHelp.SetPadding(0, 0, 1, 1)
+	tui.tvHelp.SetDynamicColors(true)
+	tui.tvHelp.SetScrollable(true)
+	tui.tvHelp.SetScrollBarVisibility(cview.ScrollBarAuto)
+
+	tui.gridHelp = newOverlay(tui.tvHelp, layoutHelpWidth, 1)
+	tui.panels.AddPanel(panelHelp, tui.gridHelp, true, false)
+}
+
+func (tui *Tui) showHelp() {
+	text := tui.formatHelp()
+
+	// fit the overlay to the text, as far as the screen allows
+	_, _, _, height := tui.panels.GetRect()
+	tui.gridHelp.SetRows(0, min(strings.Count(text, "\n")+3, max(height-2, 3)), 0)
+
+	tui.tvHelp.SetText(text)
+	tui.tvHelp.ScrollToBeginning()
+
+	tui.helpFocus = tui.app.GetFocus()
+	tui.helpShown = true
+	tui.panels.ShowPanel(panelHelp)
+	tui.app.SetFocus(tui.tvHelp)
+}
+
+func (tui *Tui) hideHelp() {
+	tui.helpShown = false
+	tui.panels.HidePanel(panelHelp)
+	tui.app.SetFocus(tui.helpFocus)
+}
+
+func (tui *Tui) helpEventHandler(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyEnter, tcell.KeyF1:
+		tui.hideHelp()
+		return nil
+	case tcell.KeyRune:
+		if event.Rune() == '?' || event.Rune() == 'q' {
+			tui.hideHelp()
+			return nil
+		}
+	}
+
+	// anything else scrolls the help
+	return event
+}
+
+func (tui *Tui) formatHelp() string {
+	var sb strings.Builder
+
+	line := func(keys string, name string, description string) {
+		sb.WriteString(fmt.Sprintf("[::b]%s[::-] %s [::d]%s[::-]\n", cview.Escape(fmt.Sprintf("%-8s", keys)), cview.Escape(fmt.Sprintf("%-24s", name)), description))
+	}
+
+	sb.WriteString(fmt.Sprintf("[%s::b]Keys and commands[-::-]\n", theme.Tag(theme.Current().StatusInfo)))
+
+	for _, command := range tui.commands {
+		line(commandKeys(command), strings.TrimSpace(command.name+" "+command.args), command.description)
+	}
+
+	sb.WriteString(fmt.Sprintf("\n[%s::b]Log browser[-::-]\n", theme.Tag(theme.Current().StatusInfo)))
+
+	for _, help := range logKeyHelp {
+		line(help[0], "", help[1])
+	}
+
+	sb.WriteString("\n[::d]Commands without keys are typed in the palette, ex: arm 5, marker Chorus\nPress Esc to close[::-]")
+
+	return sb.String()
+}
+
+// commandKeys returns the keys of a command as shown in the help
+func commandKeys(command *tuiCommand) string {
+	keys := make([]string, 0)
+
+	for _, r := range command.runes {
+		keys = append(keys, string(r))
+	}
+
+	for _, key := range command.keys {
+		keys = append(keys, tcell.KeyNames[key])
+	}
+
+	return strings.Join(keys, "/")
+}
+
+//
+// command palette
+//
+
+func (tui *Tui) initializeCommandPalette() {
+	tui.inputCommand = cview.NewInputField()
+	tui.inputCommand.SetLabel("> ")
+	tui.inputCommand.SetChangedFunc(func(text string) {
+		tui.updateCommandHint(text, nil)
+	})
+	tui.inputCommand.SetDoneFunc(tui.commandDone)
+
+	tui.tvCommandHint = cview.NewTextView()
+	tui.tvCommandHint.SetDynamicColors(true)
+	tui.tvCommandHint.SetScrollBarVisibility(cview.ScrollBarNever)
+
+	gridCommand := cview.NewGrid()
+	gridCommand.SetBorder(true)
+	gridCommand.SetTitle(" Command ")
+	gridCommand.SetPadding(0, 0, 1, 1)
+	gridCommand.SetColumns(-1)
+	gridCommand.SetRows(1, 1, -1)
+	gridCommand.AddItem(tui.inputCommand, 0, 0, 1, 1, 0, 0, true)
+	gridCommand.AddItem(tui.tvCommandHint, 2, 0, 1, 1, 0, 0, false)
+
+	grid := newOverlay(gridCommand, layoutCommandWidth, layoutCommandHeight)
+	tui.panels.AddPanel(panelCommand, grid, true, false)
+}
+
+func (tui *Tui) showCommandPalette() {
+	tui.inputCommand.SetText("")
+	tui.updateCommandHint("", nil)
+
+	tui.commandFocus = tui.app.GetFocus()
+	tui.commandShown = true
+	tui.panels.ShowPanel(panelCommand)
+	tui.app.SetFocus(tui.inputCommand)
+}
+
+func (tui *Tui) hideCommandPalette() {
+	tui.commandShown = false
+	tui.panels.HidePanel(panelCommand)
+	tui.app.SetFocus(tui.commandFocus)
+}
+
+// commandDone runs the command on enter, completes its name on tab and closes
+// the palette on escape. A failed command keeps the palette open.
+func (tui *Tui) commandDone(key tcell.Key) {
+	text := tui.inputCommand.GetText()
+
+	switch key {
+	case tcell.KeyEsc:
+		tui.hideCommandPalette()
+	case tcell.KeyTab:
+		if matches := tui.matchCommands(text); len(matches) > 0 {
+			tui.inputCommand.SetText(matches[0].name + " ")
+		}
+	case tcell.KeyEnter:
+		command, args, err := tui.parseCommand(text)
+		if err == nil {
+			// the help and palette replace this overlay
+			tui.hideCommandPalette()
+			err = command.run(tui, args)
+
+			if err != nil {
+				tui.showCommandPalette()
+				tui.inputCommand.SetText(text)
+			}
+		}
+
+		if err != nil {
+			tui.updateCommandHint(text, err)
+		}
+	}
+}
+
+// matchCommands returns the commands whose name starts with the text
+func (tui *Tui) matchCommands(text string) []*tuiCommand {
+	text = strings.ToLower(strings.TrimLeft(text, " "))
+	matches := make([]*tuiCommand, 0)
+
+	for _, command := range tui.commands {
+		if strings.HasPrefix(command.name, text) {
+			matches = append(matches, command)
+		}
+	}
+
+	return matches
+}
+
+// updateCommandHint lists the matching commands below the palette, or the
+// usage of the command being typed
+func (tui *Tui) updateCommandHint(text string, err error) {
+	var sb strings.Builder
+
+	if err != nil {
+		sb.WriteString(fmt.Sprintf("[%s]%s[-]\n", theme.Tag(theme.Current().StatusError), cview.Escape(err.Error())))
+	}
+
+	matches := tui.matchCommands(text)
+	if len(matches) == 0 {
+		if command, _, _ := tui.parseCommand(text); command != nil {
+			matches = append(matches, command)
+		}
+	}
+
+	for _, command := range matches {
+		sb.WriteString(fmt.Sprintf("[::b]%s[::-] [::d]%s[::-]\n", cview.Escape(fmt.Sprintf("%-22s", strings.TrimSpace(command.name+" "+command.args))), command.description))
+	}
+
+	tui.tvCommandHint.SetText(sb.String())
+	tui.tvCommandHint.ScrollToBeginning()
+}
+
+// newOverlay centers an item over the main grid, a height below 1 is set when
+// the overlay is shown
+func newOverlay(item cview.Primitive, width int, height int) *cview.Grid {
+	grid := cview.NewGrid()
+	grid.SetBackgroundTransparent(true)
+	grid.SetColumns(0, width, 0)
+	grid.SetRows(0, height, 0)
+	grid.AddItem(item, 1, 1, 1, 1, 0, 0, true)
+
+	return grid
+}
@@ -0,0 +1,308 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"fox-audio/display/theme"
+	"fox-audio/reaper"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// oldest log entries are dropped past this
+	logMaxEntries = 10000
+
+	logFileSuffix = "_log.txt"
+)
+
+type logEntry struct {
+	time    time.Time
+	level   slog.Level
+	message string
+}
+
+// log level filters, each shows its level and everything more severe. Debug
+// includes trace.
+var logLevelFilters = []struct {
+	name  string
+	level slog.Level
+}{
+	{"error", slog.LevelError},
+	{"warn", slog.LevelWarn},
+	{"info", slog.LevelInfo},
+	{"debug", slog.LevelDebug - 4},
+}
+
+// initializeLogs creates the log view with a status line below it, which is
+// swapped for the search field while searching
+func (tui *Tui) initializeLogs() {
+	tui.tvLogs = cview.NewTextView()
+	tui.tvLogs.SetPadding(0, 0, 0, 0)
+	tui.tvLogs.SetDynamicColors(true)
+	tui.tvLogs.SetMaxLines(logMaxEntries)
+
+	tui.tvLogStatus = cview.NewTextView()
+	tui.tvLogStatus.SetDynamicColors(true)
+	tui.tvLogStatus.SetScrollBarVisibility(cview.ScrollBarNever)
+
+	tui.inputLogSearch = cview.NewInputField()
+	tui.inputLogSearch.SetLabel("search: ")
+	tui.inputLogSearch.SetChangedFunc(func(text string) {
+		tui.setLogFilter(tui.logFilter, text)
+	})
+	tui.inputLogSearch.SetDoneFunc(tui.endLogSearch)
+
+	tui.gridLogs = cview.NewGrid()
+	tui.gridLogs.SetPadding(0, 0, 0, 0)
+	tui.gridLogs.SetColumns(-1)
+	tui.gridLogs.SetRows(-1, 1)
+	tui.gridLogs.AddItem(tui.tvLogs, 0, 0, 1, 1, 0, 0, true)
+	tui.gridLogs.AddItem(tui.tvLogStatus, 1, 0, 1, 1, 0, 0, false)
+
+	tui.logFilter = len(logLevelFilters) - 1
+	tui.logFollow = true
+	tui.updateLogStatus()
+}
+
+func (tui *Tui) WriteLevelLog(level slog.Level, message string) {
+	entry := logEntry{time: time.Now(), level: level, message: message}
+
+	tui.logMutex.Lock()
+	defer tui.logMutex.Unlock()
+
+	tui.logEntries = append(tui.logEntries, entry)
+	if len(tui.logEntries) > logMaxEntries {
+		tui.logEntries = tui.logEntries[len(tui.logEntries)-logMaxEntries:]
+	}
+
+	if tui.logVisible(entry) {
+		tui.tvLogs.Write([]byte(formatLogEntry(entry)))
+
+		if tui.logFollow {
+			tui.tvLogs.ScrollToEnd()
+		}
+	}
+}
+
+// logEventHandler handles keys while browsing the logs, scrolling is left to
+// the text view
+func (tui *Tui) logEventHandler(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyTab:
+		tui.logBrowsing = false
+		tui.updateLogStatus()
+		return nil
+	case tcell.KeyCtrlC:
+		reaper.Reap()
+		return nil
+	case tcell.KeyUp, tcell.KeyPgUp, tcell.KeyHome:
+		tui.setLogFollow(false)
+		return event
+	case tcell.KeyEnd:
+		tui.setLogFollow(true)
+		return event
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case '1', '2', '3', '4':
+			tui.setLogFilter(int(event.Rune()-'1'), tui.logSearch)
+		case '/':
+			tui.startLogSearch()
+		case 'f':
+			tui.setLogFollow(!tui.logFollow)
+		case 'w':
+			tui.saveLog()
+		case 'k', 'g':
+			tui.setLogFollow(false)
+			return event
+		case 'G':
+			tui.setLogFollow(true)
+			return event
+		case 'j':
+			return event
+		}
+
+		return nil
+	}
+
+	return event
+}
+
+func (tui *Tui) browseLogs() {
+	tui.logBrowsing = true
+	tui.updateLogStatus()
+}
+
+// setLogFollow keeps the newest entry in view, or holds the view where it is
+func (tui *Tui) setLogFollow(follow bool) {
+	tui.logMutex.Lock()
+	tui.logFollow = follow
+	tui.logMutex.Unlock()
+
+	if follow {
+		tui.tvLogs.ScrollToEnd()
+	} else {
+		row, column := tui.tvLogs.GetScrollOffset()
+		tui.tvLogs.ScrollTo(row, column)
+	}
+
+	tui.updateLogStatus()
+}
+
+func (tui *Tui) startLogSearch() {
+	tui.logSearching = true
+	tui.inputLogSearch.SetText(tui.logSearch)
+
+	tui.gridLogs.RemoveItem(tui.tvLogStatus)
+	tui.gridLogs.AddItem(tui.inputLogSearch, 1, 0, 1, 1, 0, 0, true)
+	tui.app.SetFocus(tui.inputLogSearch)
+}
+
+// endLogSearch keeps the search on enter and clears it on escape
+func (tui *Tui) endLogSearch(key tcell.Key) {
+	if key == tcell.KeyEsc {
+		tui.setLogFilter(tui.logFilter, "")
+	}
+
+	tui.logSearching = false
+
+	tui.gridLogs.RemoveItem(tui.inputLogSearch)
+	tui.gridLogs.AddItem(tui.tvLogStatus, 1, 0, 1, 1, 0, 0, false)
+	tui.app.SetFocus(tui.tvLogs)
+	tui.updateLogStatus()
+}
+
+// setLogFilter changes which entries are shown. The filters are only changed
+// here, on the UI goroutine, but every goroutine that logs reads them under
+// logMutex.
+func (tui *Tui) setLogFilter(filter int, search string) {
+	tui.logMutex.Lock()
+	tui.logFilter = filter
+	tui.logSearch = search
+	tui.logMutex.Unlock()
+
+	tui.rebuildLogs()
+}
+
+// rebuildLogs redraws the log view after the filters change
+func (tui *Tui) rebuildLogs() {
+	tui.logMutex.Lock()
+	defer tui.logMutex.Unlock()
+
+	var sb strings.Builder
+	for _, entry := range tui.logEntries {
+		if tui.logVisible(entry) {
+			sb.WriteString(formatLogEntry(entry))
+		}
+	}
+
+	tui.tvLogs.SetText(sb.String())
+
+	if tui.logFollow {
+		tui.tvLogs.ScrollToEnd()
+	}
+
+	tui.updateLogStatus()
+}
+
+func (tui *Tui) logVisible(entry logEntry) bool {
+	if entry.level < logLevelFilters[tui.logFilter].level {
+		return false
+	}
+
+	return tui.logSearch == "" || strings.Contains(strings.ToLower(entry.message), strings.ToLower(tui.logSearch))
+}
+
+func (tui *Tui) updateLogStatus() {
+	status := make([]string, 0)
+
+	if tui.logBrowsing {
+		status = append(status, "[::r] LOGS [::-]")
+	}
+
+	if tui.logBrowsing || tui.logFilter != len(logLevelFilters)-1 {
+		status = append(status, "level: "+logLevelFilters[tui.logFilter].name)
+	}
+
+	if tui.logSearch != "" {
+		status = append(status, "search: "+cview.Escape(tui.logSearch))
+	}
+
+	if !tui.logFollow {
+		status = append(status, "[::b]paused[::-]")
+	}
+
+	if tui.logBrowsing {
+		status = append(status, "[::d]1-4 level  / search  f follow  w save  esc done[::-]")
+	} else {
+		status = append(status, "[::d]l browse logs  ? help  : command[::-]")
+	}
+
+	tui.tvLogStatus.SetText(strings.Join(status, "  "))
+}
+
+// saveLog writes every entry in the buffer, unfiltered, to the take directory
+func (tui *Tui) saveLog() {
+	if tui.directory == "" || tui.takeName == "" {
+		slog.Error("Unable to save the log, the take directory isn't known yet")
+		return
+	}
+
+	tui.logMutex.Lock()
+	var sb strings.Builder
+	for _, entry := range tui.logEntries {
+		sb.WriteString(fmt.Sprintf("%s %s %s\n", entry.time.Format("2006-01-02 15:04:05.000"), entry.level.String(), entry.message))
+	}
+	tui.logMutex.Unlock()
+
+	logPath := path.Join(tui.directory, tui.takeName+logFileSuffix)
+
+	if err := os.WriteFile(logPath, []byte(sb.String()), 0644); err != nil {
+		slog.Error("Failed to save the log: " + err.Error())
+		return
+	}
+
+	slog.Info("Saved the log to " + logPath)
+}
+
+func formatLogEntry(entry logEntry) string {
+	color := "-"
+
+	if entry.level == slog.LevelWarn {
+		color = theme.Tag(theme.Current().LogWarn)
+	} else if entry.level == slog.LevelError {
+		color = theme.Tag(theme.Current().LogError) + "::b"
+	} else if entry.level == slog.LevelDebug {
+		color = theme.Tag(theme.Current().LogDebug)
+	}
+
+	return fmt.Sprintf("[%s][%s[] [%s[] %s[-:-:-]\n", color, entry.time.Format("2006-01-02 15:04:05"), entry.level.String(), cview.Escape(entry.message))
+}
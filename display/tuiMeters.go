@@ -0,0 +1,438 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"fox-audio/display/custom"
+	"fox-audio/display/theme"
+	"fox-audio/model"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// meter width when the full width strips don't fit the terminal
+	layoutMeterNarrowWidth = 3
+	layoutMeterStepsWidth  = 5
+
+	// balance shown at the end of the indicator
+	balanceRangeDb = 12.0
+)
+
+// meterGroup is a strip on the meter bridge holding the meters of one profile
+// channel, with correlation and balance indicators for 2 port channels
+type meterGroup struct {
+	name  string
+	ports []int
+
+	label       *cview.TextView
+	correlation *custom.CenterMeter
+	balance     *custom.CenterMeter
+}
+
+// SetChannelGroups arranges the level meters into strips, one per profile
+// channel. Ports that don't belong to a channel get a strip of their own.
+func (tui *Tui) SetChannelGroups(groups []model.UiChannelGroup) {
+	assigned := make(map[int]bool)
+	tui.meterGroups = make([]*meterGroup, 0, len(tui.elementLevelMeters))
+
+	for _, group := range groups {
+		ports := make([]int, 0, len(group.Ports))
+
+		for _, port := range group.Ports {
+			if port >= 0 && port < len(tui.elementLevelMeters) && !assigned[port] {
+				ports = append(ports, port)
+				assigned[port] = true
+			}
+		}
+
+		if len(ports) > 0 {
+			tui.meterGroups = append(tui.meterGroups, newMeterGroup(group.Name, ports))
+		}
+	}
+
+	for port := range tui.elementLevelMeters {
+		if !assigned[port] {
+			tui.meterGroups = append(tui.meterGroups, newMeterGroup("", []int{port}))
+		}
+	}
+
+	// keep the bridge in port order
+	slices.SortStableFunc(tui.meterGroups, func(a, b *meterGroup) int {
+		return a.ports[0] - b.ports[0]
+	})
+
+	tui.layoutLevelMeters()
+}
+
+func (tui *Tui) UpdateStereoLevels(levels []model.StereoLevel) {
+	for _, level := range levels {
+		if len(level.Ports) == 0 {
+			continue
+		}
+
+		for _, group := range tui.meterGroups {
+			if group.correlation == nil || group.ports[0] != level.Ports[0] {
+				continue
+			}
+
+			group.correlation.SetValue(level.Correlation)
+			group.correlation.SetMarkerColor(correlationColor(level.Correlation))
+
+			group.balance.SetValue(level.Balance / balanceRangeDb)
+			group.balance.SetMarkerColor(balanceColor(level.Balance))
+		}
+	}
+}
+
+func newMeterGroup(name string, ports []int) *meterGroup {
+	group := &meterGroup{
+		name:  name,
+		ports: ports,
+		label: cview.NewTextView(),
+	}
+
+	group.label.SetTextAlign(cview.AlignCenter)
+	group.label.SetDynamicColors(true)
+
+	if len(ports) == 2 {
+		group.correlation = custom.NewCenterMeter('-', '+')
+		group.balance = custom.NewCenterMeter('L', 'R')
+	}
+
+	return group
+}
+
+func (tui *Tui) hasStereoGroups() bool {
+	for _, group := range tui.meterGroups {
+		if group.correlation != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// currentMeterScale is the scale the meters are drawn with, which is half
+// height in compact mode
+func (tui *Tui) currentMeterScale() *meterScale {
+	if tui.meterCompact {
+		return tui.meterScaleCompact
+	}
+
+	return tui.meterScale
+}
+
+// meterAreaHeight is the height of the level meter row of the main grid
+func (tui *Tui) meterAreaHeight() int {
+	// group label, channel number, clip indicator, steps and max value
+	height := len(tui.currentMeterScale().steps) + 4
+
+	if tui.hasStereoGroups() {
+		// correlation and balance
+		height += 2
+	}
+
+	return height
+}
+
+// meterBanks picks the widest meters that fit every strip on screen. When
+// even the narrow meters don't fit, the strips are split into banks, returned
+// as the index of the first strip of each bank.
+func (tui *Tui) meterBanks() (int, []int) {
+	for _, width := range []int{layoutMeterWidth, layoutMeterNarrowWidth} {
+		if tui.meterAreaWidth <= 0 || len(tui.elementLevelMeters)*width <= tui.meterAreaWidth {
+			return width, []int{0}
+		}
+	}
+
+	banks := []int{0}
+	used := 0
+
+	for i, group := range tui.meterGroups {
+		groupWidth := len(group.ports) * layoutMeterNarrowWidth
+
+		if used > 0 && used+groupWidth > tui.meterAreaWidth {
+			banks = append(banks, i)
+			used = 0
+		}
+
+		used += groupWidth
+	}
+
+	return layoutMeterNarrowWidth, banks
+}
+
+// currentMeterBank is the bank holding the first strip on screen
+func (tui *Tui) currentMeterBank(banks []int) int {
+	bank := 0
+
+	for i, start := range banks {
+		if start <= tui.meterOffset {
+			bank = i
+		}
+	}
+
+	return bank
+}
+
+// visibleMeterGroups returns the strips that fit on screen, starting with the
+// strip at the current offset
+func (tui *Tui) visibleMeterGroups(meterWidth int) []*meterGroup {
+	used := 0
+
+	for i := tui.meterOffset; i < len(tui.meterGroups); i++ {
+		groupWidth := len(tui.meterGroups[i].ports) * meterWidth
+
+		if tui.meterAreaWidth > 0 && used > 0 && used+groupWidth > tui.meterAreaWidth {
+			return tui.meterGroups[tui.meterOffset:i]
+		}
+
+		used += groupWidth
+	}
+
+	return tui.meterGroups[tui.meterOffset:]
+}
+
+// pageMeters jumps to the start of the next or previous bank
+func (tui *Tui) pageMeters(direction int) {
+	_, banks := tui.meterBanks()
+	bank := tui.currentMeterBank(banks)
+
+	if direction > 0 {
+		if bank+1 < len(banks) {
+			tui.meterOffset = banks[bank+1]
+		}
+	} else if tui.meterOffset > banks[bank] {
+		tui.meterOffset = banks[bank]
+	} else if bank > 0 {
+		tui.meterOffset = banks[bank-1]
+	}
+
+	tui.layoutLevelMeters()
+}
+
+// scrollMeters moves the bridge by a single strip
+func (tui *Tui) scrollMeters(direction int) {
+	_, banks := tui.meterBanks()
+
+	tui.meterOffset = max(min(tui.meterOffset+direction, banks[len(banks)-1]), 0)
+	tui.layoutLevelMeters()
+}
+
+// labelMeterGroup labels the strip with the channel name and each meter with
+// its port number, or with its position in the channel when showing names.
+// Strips without an armed port are dimmed.
+func (tui *Tui) labelMeterGroup(group *meterGroup, meterWidth int) {
+	armed := false
+	for _, port := range group.ports {
+		armed = armed || tui.elementLevelMeters[port].IsArmed()
+	}
+
+	style := "[::b]"
+	if !armed {
+		style = "[::d]"
+	}
+
+	showNames := tui.meterNameLabels && group.name != ""
+
+	// in number mode only multi-port channels are labelled on the strip
+	if showNames || len(group.ports) > 1 {
+		group.label.SetText(style + cview.Escape(truncate(group.name, len(group.ports)*meterWidth)) + "[::-]")
+	} else {
+		group.label.SetText("")
+	}
+
+	for i, port := range group.ports {
+		label := strconv.Itoa(port + 1)
+
+		if showNames {
+			switch len(group.ports) {
+			case 1:
+				label = ""
+			case 2:
+				label = []string{"L", "R"}[i]
+			default:
+				label = strconv.Itoa(i + 1)
+			}
+		}
+
+		tui.elementLevelMeters[port].SetChannelNumber(label)
+	}
+}
+
+// toggleMeterLabels switches the meters between channel names and port numbers
+func (tui *Tui) toggleMeterLabels() {
+	tui.meterNameLabels = !tui.meterNameLabels
+	tui.layoutLevelMeters()
+}
+
+func (tui *Tui) toggleCompactMeters() {
+	tui.meterCompact = !tui.meterCompact
+
+	for _, meter := range tui.elementLevelMeters {
+		meter.SetMeterSteps(tui.currentMeterScale().steps)
+	}
+
+	tui.writeMeterSteps()
+	tui.layoutLevelMeters()
+}
+
+// resized fits the meter bridge to the new terminal width
+func (tui *Tui) resized(width int, height int) {
+	// borders of the main grid, the output file list and the step labels
+	tui.meterAreaWidth = width - layoutOutputFileColumnWidth - 3 - layoutMeterStepsWidth
+	tui.layoutLevelMeters()
+}
+
+func (tui *Tui) writeMeterSteps() {
+	if tui.tvMeterSteps == nil {
+		return
+	}
+
+	tui.tvMeterSteps.Clear()
+	tui.tvMeterSteps.Write([]byte(fmt.Sprintln()))
+	tui.tvMeterSteps.Write([]byte(fmt.Sprintln("clp")))
+	for _, label := range tui.currentMeterScale().labels {
+		tui.tvMeterSteps.Write([]byte(fmt.Sprintf("%3v\n", label)))
+	}
+}
+
+func (tui *Tui) layoutLevelMeters() {
+	tui.gridLevelMeters.Clear()
+
+	if tui.tvMeterSteps == nil {
+		return
+	}
+
+	meterWidth, banks := tui.meterBanks()
+	tui.meterOffset = max(min(tui.meterOffset, len(tui.meterGroups)-1), 0)
+	groups := tui.visibleMeterGroups(meterWidth)
+
+	rows := []int{1, len(tui.currentMeterScale().steps) + 3}
+	columns := []int{layoutMeterStepsWidth}
+
+	tui.gridLevelMeters.AddItem(tui.tvMeterSteps, 1, 0, 1, 1, 0, 0, false)
+
+	if tui.hasStereoGroups() {
+		rows = append(rows, 1, 1)
+
+		tvCorrelation := cview.NewTextView()
+		tvCorrelation.SetText("cor")
+		tui.gridLevelMeters.AddItem(tvCorrelation, 2, 0, 1, 1, 0, 0, false)
+
+		tvBalance := cview.NewTextView()
+		tvBalance.SetText("bal")
+		tui.gridLevelMeters.AddItem(tvBalance, 3, 0, 1, 1, 0, 0, false)
+	}
+
+	column := 1
+	firstPort := len(tui.elementLevelMeters)
+	lastPort := 0
+
+	for i, group := range groups {
+		background := cview.Styles.PrimitiveBackgroundColor
+		if (i+tui.meterOffset)%2 == 1 {
+			background = theme.Current().MeterAlternateBackground
+		}
+
+		tui.labelMeterGroup(group, meterWidth)
+		group.label.SetBackgroundColor(background)
+		tui.gridLevelMeters.AddItem(group.label, 0, column, 1, len(group.ports), 0, 0, false)
+
+		for _, port := range group.ports {
+			meter := tui.elementLevelMeters[port]
+			meter.SetBackgroundColor(background)
+
+			if meterWidth < layoutMeterWidth {
+				meter.SetPadding(0, 0, 0, 1)
+			} else {
+				meter.SetPadding(0, 0, 1, 1)
+			}
+
+			tui.gridLevelMeters.AddItem(meter, 1, column, 1, 1, 0, 0, false)
+
+			columns = append(columns, meterWidth)
+			column++
+
+			firstPort = min(firstPort, port)
+			lastPort = max(lastPort, port)
+		}
+
+		if group.correlation != nil {
+			group.correlation.SetBackgroundColor(background)
+			group.balance.SetBackgroundColor(background)
+
+			tui.gridLevelMeters.AddItem(group.correlation, 2, column-2, 1, 2, 0, 0, false)
+			tui.gridLevelMeters.AddItem(group.balance, 3, column-2, 1, 2, 0, 0, false)
+		}
+	}
+
+	// bank indicator, only shown when the strips don't fit on screen
+	if len(banks) > 1 {
+		tvBank := cview.NewTextView()
+		tvBank.SetDynamicColors(true)
+		tvBank.SetText(fmt.Sprintf("[::b]%d/%d[::-]", tui.currentMeterBank(banks)+1, len(banks)))
+		tui.gridLevelMeters.AddItem(tvBank, 0, 0, 1, 1, 0, 0, false)
+
+		tvBankHint := cview.NewTextView()
+		tvBankHint.SetDynamicColors(true)
+		tvBankHint.SetPadding(0, 0, 1, 0)
+		tvBankHint.SetScrollBarVisibility(cview.ScrollBarNever)
+		tvBankHint.SetText(fmt.Sprintf("[::d]inputs %d-%d of %d[::-]", firstPort+1, lastPort+1, len(tui.elementLevelMeters)))
+		tui.gridLevelMeters.AddItem(tvBankHint, 0, column, 1, 1, 0, 0, false)
+	}
+
+	columns = append(columns, -1)
+
+	tui.gridLevelMeters.SetColumns(columns...)
+	tui.gridLevelMeters.SetRows(rows...)
+
+	tui.layoutApp()
+}
+
+func correlationColor(correlation float64) tcell.Color {
+	if correlation < 0 {
+		return theme.Current().StatusError
+	} else if correlation < 0.3 {
+		return theme.Current().StatusWarn
+	}
+
+	return theme.Current().StatusOk
+}
+
+func balanceColor(balance float64) tcell.Color {
+	if math.Abs(balance) > 6 {
+		return theme.Current().StatusError
+	} else if math.Abs(balance) > 3 {
+		return theme.Current().StatusWarn
+	}
+
+	return theme.Current().StatusOk
+}
@@ -0,0 +1,133 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"fmt"
+	"strconv"
+
+	"fox-audio/display/custom"
+	"fox-audio/model"
+)
+
+const (
+	// the spectrum shares the space left below the meters with the logs,
+	// taking 2 parts to their 1
+	layoutSpectrumRowProportion = -2
+
+	spectrumMinLevel = -72.0
+
+	// octave bands are labelled, the 1/3 octaves between them are not
+	spectrumLabelInterval = 3
+	spectrumLabelOffset   = 2
+)
+
+func (tui *Tui) SetActionHandler(handler ActionHandler) {
+	tui.actionHandler = handler
+}
+
+// UpdateSpectrum shows the analyzed bands, levels of a port that is no longer
+// selected are dropped
+func (tui *Tui) UpdateSpectrum(spectrum *model.Spectrum) {
+	if !tui.spectrumShown || spectrum.Port != tui.spectrumPort {
+		return
+	}
+
+	labels := make([]string, len(spectrum.Bands))
+	levels := make([]float64, len(spectrum.Bands))
+	peaks := make([]float64, len(spectrum.Bands))
+
+	for i, band := range spectrum.Bands {
+		if i%spectrumLabelInterval == spectrumLabelOffset {
+			labels[i] = formatFrequency(band.Frequency)
+		}
+
+		levels[i] = band.Level
+		peaks[i] = band.Peak
+	}
+
+	tui.spectrumView.SetLabels(labels)
+	tui.spectrumView.SetLevels(levels, peaks)
+}
+
+func (tui *Tui) toggleSpectrum() {
+	if len(tui.elementLevelMeters) == 0 {
+		return
+	}
+
+	tui.spectrumShown = !tui.spectrumShown
+
+	if tui.spectrumShown {
+		tui.spectrumPort = min(tui.spectrumPort, len(tui.elementLevelMeters)-1)
+		tui.spectrumView.SetLevels(nil, nil)
+		tui.updateSpectrumTitle()
+		tui.runAction(ActionSpectrumPort, strconv.Itoa(tui.spectrumPort))
+	} else {
+		tui.runAction(ActionSpectrumPort, "-1")
+	}
+
+	tui.layoutApp()
+}
+
+// selectSpectrumPort moves the analyzer to the next or previous port
+func (tui *Tui) selectSpectrumPort(offset int) {
+	portCount := len(tui.elementLevelMeters)
+
+	if !tui.spectrumShown || portCount == 0 {
+		return
+	}
+
+	tui.spectrumPort = (tui.spectrumPort + offset + portCount) % portCount
+	tui.spectrumView.SetLevels(nil, nil)
+	tui.updateSpectrumTitle()
+	tui.runAction(ActionSpectrumPort, strconv.Itoa(tui.spectrumPort))
+}
+
+func (tui *Tui) updateSpectrumTitle() {
+	source := fmt.Sprintf("input %d", tui.spectrumPort+1)
+
+	for _, group := range tui.meterGroups {
+		for _, port := range group.ports {
+			if port == tui.spectrumPort && group.name != "" {
+				source += " (" + group.name + ")"
+			}
+		}
+	}
+
+	tui.spectrumView.SetTitle(fmt.Sprintf("[::b]Spectrum[::-] %s  [::d][ ] select input, s hide[::-]", source))
+}
+
+func newSpectrumView() *custom.SpectrumView {
+	view := custom.NewSpectrumView(spectrumMinLevel)
+	view.SetPadding(0, 0, 1, 1)
+
+	return view
+}
+
+func formatFrequency(frequency float64) string {
+	if frequency >= 1000 {
+		return strconv.FormatFloat(frequency/1000, 'f', -1, 64) + "k"
+	}
+
+	return strconv.FormatFloat(frequency, 'f', 0, 64)
+}
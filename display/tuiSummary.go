@@ -0,0 +1,194 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"fox-audio/display/theme"
+	"fox-audio/model"
+	"fox-audio/util"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/gdamore/tcell/v2"
+)
+
+// ShowSummary draws the session summary over the main grid and blocks until
+// the operator dismisses it
+func (tui *Tui) ShowSummary(summary *model.SessionSummary) {
+	if tui.IsShutdown() {
+		return
+	}
+
+	tvSummary := cview.NewTextView()
+	tvSummary.SetBorder(true)
+	tvSummary.SetTitle(" Session Summary ")
+	tvSummary.SetPadding(0, 0, 1, 1)
+	tvSummary.SetDynamicColors(true)
+	tvSummary.SetScrollable(true)
+	tvSummary.SetText(formatSummary(summary) + "\n[::d]Press Enter to exit[::-]")
+
+	tui.app.QueueUpdateDraw(func() {
+		tui.panels.AddPanel(panelSummary, tvSummary, true, true)
+		tui.app.SetFocus(tvSummary)
+		tui.summaryShown = true
+	})
+
+	<-tui.summaryAck
+}
+
+func (tui *Tui) summaryEventHandler(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyEnter, tcell.KeyEsc, tcell.KeyCtrlC:
+		tui.summaryShown = false
+		tui.summaryAck <- true
+		return nil
+	case tcell.KeyRune:
+		if event.Rune() == 'q' {
+			tui.summaryShown = false
+			tui.summaryAck <- true
+			return nil
+		}
+	}
+
+	// anything else (arrows, page up/down) scrolls the summary
+	return event
+}
+
+func formatSummary(summary *model.SessionSummary) string {
+	var sb strings.Builder
+
+	field := func(name string, value string) {
+		sb.WriteString(fmt.Sprintf("[::b]%-16s[::-] %s\n", name+":", value))
+	}
+
+	field("Profile", summary.ProfileName)
+	field("Take", summary.Take)
+	field("Directory", summary.Directory)
+	field("Duration", util.FormatDuration(summary.Duration))
+	field("Disk Free", util.FormatSize(summary.DiskFree))
+	field("Xruns", countValue(uint64(summary.XrunCount), 0))
+	field("Dropped Samples", countValue(summary.DroppedSamples, 0))
+	field("Errors", countValue(uint64(summary.ErrorCount), 0))
+
+	sb.WriteString(fmt.Sprintf("\n[::b]%-*s %*s %*s %10s  %s[::-]\n",
+		layoutSummaryNameWidth, "Channel",
+		layoutSummarySizeWidth, "Size",
+		layoutSummaryPeakWidth, "Peak",
+		"Clips", "Status"))
+
+	var totalSize uint64
+	for _, file := range summary.Files {
+		totalSize += file.Size
+
+		name := truncate(file.Name, layoutSummaryNameWidth)
+
+		peak := "-inf"
+		if file.PeakDbfs > -150 {
+			peak = fmt.Sprintf("%.1f dB", file.PeakDbfs)
+		}
+
+		sb.WriteString(fmt.Sprintf("%-*s %*s %*s %s  %s\n",
+			layoutSummaryNameWidth, name,
+			layoutSummarySizeWidth, util.FormatSize(file.Size),
+			layoutSummaryPeakWidth, peak,
+			countValue(file.ClippedSamples, 10),
+			healthValue(file.Health)))
+	}
+
+	sb.WriteString(fmt.Sprintf("%-*s %*s\n", layoutSummaryNameWidth, "Total", layoutSummarySizeWidth, util.FormatSize(totalSize)))
+
+	if len(summary.Loudness) > 0 {
+		sb.WriteString("\n[::b]Loudness[::-]\n")
+
+		for _, level := range summary.Loudness {
+			sb.WriteString(formatLoudness(level) + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// countValue formats a counter right aligned to width, highlighting it in red
+// when it is non-zero. The color tags are added after padding as they take no
+// space on screen.
+func countValue(count uint64, width int) string {
+	value := fmt.Sprintf("%*d", width, count)
+
+	if count > 0 {
+		return "[" + theme.Tag(theme.Current().StatusError) + "]" + value + "[-]"
+	}
+
+	return value
+}
+
+func healthValue(health model.FileHealth) string {
+	name := model.FileHealthNames[health]
+
+	switch health {
+	case model.FileHealthFailed:
+		return "[" + theme.Tag(theme.Current().StatusError) + "]" + name + "[-]"
+	case model.FileHealthRetrying:
+		return "[" + theme.Tag(theme.Current().StatusWarn) + "]" + name + "[-]"
+	case model.FileHealthDisabled:
+		return "[::d]" + name + "[::-]"
+	}
+
+	return name
+}
+
+func formatLoudness(level model.LoudnessLevel) string {
+	truePeak := loudnessValue(level.TruePeak, "%5.1f")
+	if level.TruePeak > truePeakLimit {
+		truePeak = "[" + theme.Tag(theme.Current().StatusError) + "]" + truePeak + "[-]"
+	}
+
+	lra := "  -- "
+	if level.Integrated > -150 {
+		lra = fmt.Sprintf("%4.1f", level.Range)
+	}
+
+	return fmt.Sprintf("[::b]%-*s[::-] M %s  S %s  I %s LUFS  LRA %s LU  TP %s dBTP",
+		layoutLoudnessNameWidth, truncate(level.Name, layoutLoudnessNameWidth),
+		loudnessValue(level.Momentary, "%5.1f"),
+		loudnessValue(level.ShortTerm, "%5.1f"),
+		loudnessValue(level.Integrated, "%5.1f"),
+		lra, truePeak)
+}
+
+func loudnessValue(value float64, format string) string {
+	if value <= -150 {
+		return "  -- "
+	}
+
+	return fmt.Sprintf(format, value)
+}
+
+func truncate(value string, width int) string {
+	if len(value) > width {
+		return value[:width]
+	}
+
+	return value
+}
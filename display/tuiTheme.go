@@ -0,0 +1,107 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"fox-audio/display/custom"
+	"fox-audio/display/theme"
+	"fox-audio/model"
+)
+
+// SetTheme loads the palettes and selects the configured one. It must be
+// called before SetMeterStandard and Initalize.
+func (tui *Tui) SetTheme(options *model.ThemeOptions) error {
+	palettes, err := theme.LoadPalettes(options)
+	if err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(palettes, func(p *theme.Palette) bool { return p.Name == options.Name })
+	if index < 0 {
+		names := make([]string, len(palettes))
+		for i, palette := range palettes {
+			names[i] = palette.Name
+		}
+
+		return errors.New("invalid theme specified: " + options.Name + ". Valid options: " + strings.Join(names, ", "))
+	}
+
+	tui.palettes = palettes
+	tui.paletteIndex = index
+	theme.SetCurrent(palettes[index])
+
+	return nil
+}
+
+// cycleTheme switches to the next palette while running
+func (tui *Tui) cycleTheme() {
+	if len(tui.palettes) == 0 {
+		return
+	}
+
+	tui.paletteIndex = (tui.paletteIndex + 1) % len(tui.palettes)
+	theme.SetCurrent(tui.palettes[tui.paletteIndex])
+	tui.applyTheme()
+
+	slog.Info("Theme: " + tui.palettes[tui.paletteIndex].Name)
+}
+
+// applyTheme recolors the widgets that keep their colors between updates,
+// everything else picks up the palette the next time it is drawn or updated
+func (tui *Tui) applyTheme() {
+	palette := theme.Current()
+
+	tui.gridApp.SetBordersColor(palette.Border)
+
+	// the meter scales hold their own colors
+	tui.SetMeterStandard(tui.meterOptions)
+	for _, meter := range tui.elementLevelMeters {
+		meter.SetColorMap(tui.currentMeterScale().colors)
+	}
+
+	statusMeters := []*custom.StatusMeter{
+		tui.statusMeterDiskUsed,
+		tui.statusMeterBufferUsed,
+		tui.statusMeterCycleBufferUsed,
+		tui.statusMeterAudioLoad,
+		tui.statusMeterDiskLoad,
+	}
+
+	for _, meter := range statusMeters {
+		meter.SetEmptyColor(palette.Track)
+	}
+
+	tui.SetTransportStatus(tui.transportStatus)
+
+	if tui.errorCount > 0 {
+		tui.tvErrorCount.SetColor(palette.StatusError)
+	}
+
+	// strip backgrounds
+	tui.layoutLevelMeters()
+}
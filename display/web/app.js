@@ -0,0 +1,430 @@
+// fox web ui, talks to the control API of the fox instance that served it
+"use strict";
+
+const meterFloorDb = -60;
+const meterTicks = [0, -6, -12, -18, -24, -30, -40, -50, -60];
+const clipHoldMs = 2000;
+const logMaxEntries = 500;
+const reconnectMs = 2000;
+
+const logLevelFilters = {
+  error: 8,
+  warn: 4,
+  info: 0,
+  debug: -100,
+};
+
+let portNames = {};
+let meters = [];
+let files = [];
+let logs = [];
+let toastTimer = null;
+
+// set when the API requires a token, open the page with ?token=...
+const apiToken = new URLSearchParams(location.search).get("token");
+
+const $ = (id) => document.getElementById(id);
+
+//
+// formatting
+//
+
+function formatDuration(seconds) {
+  seconds = Math.floor(seconds || 0);
+
+  const hours = Math.floor(seconds / 3600);
+  const minutes = Math.floor(seconds / 60) % 60;
+  const secs = seconds % 60;
+
+  return hours + ":" + String(minutes).padStart(2, "0") + ":" + String(secs).padStart(2, "0");
+}
+
+function formatSize(bytes) {
+  const units = ["B", "KiB", "MiB", "GiB", "TiB"];
+  let unit = 0;
+
+  while (bytes >= 1024 && unit < units.length - 1) {
+    bytes /= 1024;
+    unit++;
+  }
+
+  return (unit === 0 ? bytes : bytes.toFixed(1)) + " " + units[unit];
+}
+
+function formatDb(db) {
+  return db <= -150 ? "-inf" : db.toFixed(1);
+}
+
+// slog levels are named relative to the nearest level, trace is DEBUG-4
+function levelValue(name) {
+  const match = /^([A-Z]+)([+-]\d+)?$/.exec(name);
+  if (!match) {
+    return 0;
+  }
+
+  const base = { DEBUG: -4, INFO: 0, WARN: 4, ERROR: 8 }[match[1]] || 0;
+
+  return base + (match[2] ? parseInt(match[2], 10) : 0);
+}
+
+function meterPercent(db) {
+  return Math.max(0, Math.min(100, (db - meterFloorDb) / -meterFloorDb * 100));
+}
+
+//
+// meter bridge
+//
+
+function buildScale() {
+  const scale = $("scale");
+  scale.innerHTML = "";
+
+  // the tracks start below the clip indicators
+  for (const tick of meterTicks) {
+    const label = document.createElement("span");
+    label.textContent = tick;
+    label.style.top = (10 + (100 - meterPercent(tick)) * 2) + "px";
+    scale.appendChild(label);
+  }
+}
+
+function buildMeters(count) {
+  const bridge = $("bridge");
+  bridge.innerHTML = "";
+  meters = [];
+
+  for (let i = 0; i < count; i++) {
+    const element = document.createElement("div");
+    element.className = "meter";
+    element.innerHTML = '<div class="clip"></div><div class="track"><div class="body"></div><div class="peak"></div></div><div class="name"></div>';
+    bridge.appendChild(element);
+
+    meters.push({
+      element: element,
+      clip: element.querySelector(".clip"),
+      body: element.querySelector(".body"),
+      peak: element.querySelector(".peak"),
+      name: element.querySelector(".name"),
+      clips: 0,
+      clipUntil: 0,
+    });
+  }
+
+  nameMeters();
+}
+
+function nameMeters() {
+  meters.forEach((meter, i) => {
+    const number = String(i + 1);
+    const name = portNames[number];
+
+    meter.name.textContent = name ? name : number;
+    meter.element.title = name ? number + ": " + name : number;
+  });
+}
+
+function updateLevels(message) {
+  if (message.ports.length !== meters.length) {
+    buildMeters(message.ports.length);
+  }
+
+  // digital meters show the RMS level as the body, the others the ballistic reading
+  const rmsBody = message.standard === "digital";
+  const now = Date.now();
+
+  message.ports.forEach((port, i) => {
+    const meter = meters[i];
+
+    meter.body.style.height = meterPercent(rmsBody ? port.rms : port.meter) + "%";
+    meter.peak.style.bottom = "calc(" + meterPercent(port.peak_hold) + "% - 2px)";
+    meter.element.classList.toggle("disarmed", !port.armed);
+
+    if (port.clips > meter.clips) {
+      meter.clipUntil = now + clipHoldMs;
+    }
+    meter.clips = port.clips;
+    meter.clip.classList.toggle("lit", now < meter.clipUntil);
+  });
+}
+
+//
+// status
+//
+
+function setBar(id, percent) {
+  const bar = $(id);
+
+  bar.style.width = Math.max(0, Math.min(100, percent)) + "%";
+  bar.classList.toggle("warn", percent >= 70 && percent < 90);
+  bar.classList.toggle("high", percent >= 90);
+}
+
+function updateStatus(message) {
+  const status = $("transport-status");
+  status.textContent = message.status;
+  status.className = "status " + message.status.toLowerCase().split(" ")[0];
+
+  $("take").textContent = message.take_name || "-";
+  $("duration").textContent = formatDuration(message.duration);
+  $("profile").textContent = message.profile_name || "-";
+  $("format").textContent = message.format || "-";
+  $("directory").textContent = message.directory || "-";
+  $("session-size").textContent = formatSize(message.session_size);
+  $("errors").textContent = message.error_count;
+
+  setBar("disk-used", message.disk_used_pct);
+  setBar("buffer-used", message.buffer_used_pct);
+  setBar("cycle-buffer-used", message.cycle_buffer_used_pct);
+  setBar("audio-load", message.audio_load_pct);
+  setBar("disk-load", message.disk_load_pct);
+
+  $("btn-record").classList.toggle("active", message.status === "Recording");
+}
+
+function updateLoudness(message) {
+  const loudness = $("loudness");
+  loudness.classList.toggle("hidden", message.meters.length === 0);
+
+  loudness.textContent = message.meters.map((meter) =>
+    meter.name + "  M " + formatDb(meter.momentary) +
+    "  S " + formatDb(meter.short_term) +
+    "  I " + formatDb(meter.integrated) +
+    "  LRA " + meter.range.toFixed(1) +
+    "  TP " + formatDb(meter.true_peak)
+  ).join("\n");
+}
+
+//
+// files
+//
+
+function updateFiles(message) {
+  const names = {};
+  for (const file of message.files) {
+    for (const port of file.ports) {
+      names[port] = file.name;
+    }
+  }
+
+  const changed = message.files.length !== files.length ||
+    message.files.some((file, i) => file.name !== files[i].name);
+
+  files = message.files;
+  portNames = names;
+
+  if (changed) {
+    buildFiles();
+    nameMeters();
+  }
+
+  files.forEach((file, i) => {
+    const row = $("files").rows[i];
+
+    row.cells[2].textContent = formatSize(file.size);
+    row.cells[3].textContent = file.health;
+    row.cells[3].className = "health-" + file.health;
+  });
+}
+
+function buildFiles() {
+  const body = $("files");
+  body.innerHTML = "";
+
+  for (const file of files) {
+    const row = body.insertRow();
+    row.insertCell().textContent = file.name;
+    row.insertCell().textContent = file.ports.join(", ");
+
+    const size = row.insertCell();
+    size.className = "size";
+    row.insertCell();
+
+    const actions = row.insertCell();
+    for (const command of ["arm", "disarm"]) {
+      const button = document.createElement("button");
+      button.className = "small";
+      button.textContent = command;
+      button.addEventListener("click", () => sendCommand(command + "/" + encodeURIComponent(file.name)));
+      actions.appendChild(button);
+    }
+  }
+}
+
+//
+// logs
+//
+
+function addLog(message) {
+  logs.push(message);
+  if (logs.length > logMaxEntries) {
+    logs.shift();
+  }
+
+  if (logVisible(message)) {
+    appendLog(message);
+  }
+}
+
+function logVisible(message) {
+  return levelValue(message.level) >= logLevelFilters[$("log-level").value];
+}
+
+function appendLog(message) {
+  const view = $("logs");
+
+  const line = document.createElement("div");
+  line.className = "log-" + message.level.replace(/[+-]\d+$/, "");
+  line.textContent = new Date(message.date).toLocaleTimeString() + " " + message.level + " " + message.message;
+  view.appendChild(line);
+
+  while (view.childElementCount > logMaxEntries) {
+    view.removeChild(view.firstChild);
+  }
+
+  if ($("log-follow").checked) {
+    view.scrollTop = view.scrollHeight;
+  }
+}
+
+function rebuildLogs() {
+  $("logs").innerHTML = "";
+
+  for (const message of logs) {
+    if (logVisible(message)) {
+      appendLog(message);
+    }
+  }
+}
+
+// the buffered log is sent when the websocket connects
+function loadLogs(message) {
+  logs = message.logs.slice(-logMaxEntries);
+  rebuildLogs();
+}
+
+//
+// summary
+//
+
+function showSummary(message) {
+  const lines = [
+    "Profile:   " + message.profile_name,
+    "Take:      " + message.take_name,
+    "Directory: " + message.directory,
+    "Duration:  " + formatDuration(message.duration),
+    "Xruns:     " + message.xrun_count,
+    "Dropped:   " + message.dropped_samples,
+    "Errors:    " + message.error_count,
+    "Disk free: " + formatSize(message.disk_free),
+    "",
+  ];
+
+  for (const file of message.files) {
+    lines.push(file.file_name + "  " + formatSize(file.size) + "  peak " + formatDb(file.peak_dbfs) + " dBFS  clips " + file.clipped_samples + "  " + file.health);
+  }
+
+  $("summary-text").textContent = lines.join("\n");
+  $("summary").classList.remove("hidden");
+}
+
+//
+// commands
+//
+
+async function sendCommand(command) {
+  try {
+    const headers = apiToken ? { Authorization: "Bearer " + apiToken } : {};
+    const response = await fetch("api/" + command, { method: "POST", headers: headers });
+    const result = await response.json();
+
+    if (!result.ok) {
+      showToast(result.error);
+    }
+  } catch (err) {
+    showToast("Command failed: " + err.message);
+  }
+}
+
+function showToast(text) {
+  const toast = $("toast");
+  toast.textContent = text;
+  toast.classList.remove("hidden");
+
+  clearTimeout(toastTimer);
+  toastTimer = setTimeout(() => toast.classList.add("hidden"), 4000);
+}
+
+//
+// connection
+//
+
+function connect() {
+  const protocol = location.protocol === "https:" ? "wss:" : "ws:";
+  const path = location.pathname.replace(/[^/]*$/, "");
+  const query = apiToken ? "?token=" + encodeURIComponent(apiToken) : "";
+  const socket = new WebSocket(protocol + "//" + location.host + path + "api/ws" + query);
+
+  socket.onopen = () => {
+    $("connection").textContent = "online";
+    $("connection").classList.remove("offline");
+  };
+
+  socket.onclose = () => {
+    $("connection").textContent = "offline";
+    $("connection").classList.add("offline");
+    setTimeout(connect, reconnectMs);
+  };
+
+  socket.onmessage = (event) => {
+    const message = JSON.parse(event.data);
+
+    switch (message.message_type) {
+      case "status":
+        updateStatus(message);
+        break;
+      case "levels":
+        updateLevels(message);
+        break;
+      case "files":
+        updateFiles(message);
+        break;
+      case "loudness":
+        updateLoudness(message);
+        break;
+      case "log":
+        addLog(message);
+        break;
+      case "logs":
+        loadLogs(message);
+        break;
+      case "summary":
+        showSummary(message);
+        break;
+    }
+  };
+}
+
+document.querySelectorAll(".transport button").forEach((button) => {
+  button.addEventListener("click", () => {
+    const command = button.dataset.command;
+
+    if (command === "stop") {
+      if (confirm("Stop recording and finish the session?")) {
+        sendCommand("stop?confirm=1");
+      }
+      return;
+    }
+
+    sendCommand(command);
+  });
+});
+
+$("log-level").addEventListener("change", rebuildLogs);
+$("log-follow").addEventListener("change", () => {
+  if ($("log-follow").checked) {
+    $("logs").scrollTop = $("logs").scrollHeight;
+  }
+});
+
+buildScale();
+connect();
@@ -0,0 +1,289 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defined by RFC 6455, hashed with the client key to accept the handshake
+	websocketGuid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	websocketOpText  = 0x1
+	websocketOpClose = 0x8
+	websocketOpPing  = 0x9
+	websocketOpPong  = 0xa
+
+	// clients only send control frames, anything bigger is dropped
+	websocketMaxPayload = 64 * 1024
+
+	websocketSendQueue    = 64
+	websocketWriteTimeout = 5 * time.Second
+)
+
+// websocketConn is a server side websocket that pushes text messages. Messages
+// from the client are read and discarded, only ping and close are answered.
+type websocketConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	send chan []byte
+	done chan bool
+
+	closeOnce  sync.Once
+	writeMutex sync.Mutex
+}
+
+// sameOrigin reports whether a browser request was made by a page served from
+// this host. Requests without an Origin header don't come from a web page.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	originUrl, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(originUrl.Host, r.Host)
+}
+
+// upgradeWebsocket completes the websocket handshake and takes over the
+// connection from the http server
+func upgradeWebsocket(w http.ResponseWriter, r *http.Request) (*websocketConn, error) {
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		return nil, errors.New("not a websocket handshake")
+	}
+
+	// browsers let any page open a websocket, the origin is all that tells
+	// our own page from someone else's
+	if !sameOrigin(r) {
+		return nil, errors.New("cross origin websocket from " + r.Header.Get("Origin") + " rejected")
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, errors.New("unsupported websocket version: " + r.Header.Get("Sec-WebSocket-Version"))
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, errors.New("missing websocket key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("connection can't be upgraded")
+	}
+
+	conn, buffer, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha1.Sum([]byte(key + websocketGuid))
+
+	buffer.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	buffer.WriteString("Upgrade: websocket\r\n")
+	buffer.WriteString("Connection: Upgrade\r\n")
+	buffer.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(hash[:]) + "\r\n\r\n")
+
+	if err := buffer.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	ws := &websocketConn{
+		conn:   conn,
+		reader: buffer.Reader,
+		send:   make(chan []byte, websocketSendQueue),
+		done:   make(chan bool),
+	}
+
+	go ws.writeLoop()
+
+	return ws, nil
+}
+
+// queue hands a message to the writer, false means the client can't keep up
+func (ws *websocketConn) queue(message []byte) bool {
+	select {
+	case <-ws.done:
+		return false
+	case ws.send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// readLoop blocks until the client goes away
+func (ws *websocketConn) readLoop() {
+	defer ws.close()
+
+	for {
+		opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return
+		}
+
+		switch opcode {
+		case websocketOpClose:
+			ws.writeFrame(websocketOpClose, nil)
+			return
+		case websocketOpPing:
+			if ws.writeFrame(websocketOpPong, payload) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (ws *websocketConn) close() {
+	ws.closeOnce.Do(func() {
+		close(ws.done)
+		ws.conn.Close()
+	})
+}
+
+//
+// private functions
+//
+
+func (ws *websocketConn) writeLoop() {
+	defer ws.close()
+
+	for {
+		select {
+		case <-ws.done:
+			return
+		case message := <-ws.send:
+			if ws.writeFrame(websocketOpText, message) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (ws *websocketConn) readFrame() (byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(ws.reader, header); err != nil {
+		return 0, nil, err
+	}
+
+	opcode := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+
+	if length == 126 {
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(ws.reader, extended); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	} else if length == 127 {
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(ws.reader, extended); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+
+	if length > websocketMaxPayload {
+		return 0, nil, errors.New("websocket frame too large")
+	}
+
+	// clients must mask every frame, RFC 6455 says to drop them otherwise
+	if !masked {
+		return 0, nil, errors.New("unmasked websocket frame")
+	}
+
+	mask := make([]byte, 4)
+	if _, err := io.ReadFull(ws.reader, mask); err != nil {
+		return 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.reader, payload); err != nil {
+		return 0, nil, err
+	}
+
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return opcode, payload, nil
+}
+
+// writeFrame sends a single unmasked, unfragmented frame
+func (ws *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|opcode)
+
+	length := len(payload)
+
+	if length < 126 {
+		frame = append(frame, byte(length))
+	} else if length <= 0xffff {
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	} else {
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+
+	frame = append(frame, payload...)
+
+	ws.writeMutex.Lock()
+	defer ws.writeMutex.Unlock()
+
+	ws.conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+	_, err := ws.conn.Write(frame)
+
+	return err
+}
+
+func headerHasToken(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
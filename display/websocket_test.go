@@ -0,0 +1,180 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package display
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+// clientFrame builds a masked frame the way a browser sends it
+func clientFrame(opcode byte, payload []byte) []byte {
+	mask := []byte{0x37, 0xfa, 0x21, 0x3d}
+
+	frame := []byte{0x80 | opcode}
+
+	length := len(payload)
+
+	if length < 126 {
+		frame = append(frame, 0x80|byte(length))
+	} else if length <= 0xffff {
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	} else {
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+
+	frame = append(frame, mask...)
+
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	return frame
+}
+
+func TestReadFrame(t *testing.T) {
+	medium := bytes.Repeat([]byte("m"), 300)
+	large := bytes.Repeat([]byte("l"), 70000)
+
+	oversized := []byte{0x82, 0x80 | 127}
+	oversized = binary.BigEndian.AppendUint64(oversized, websocketMaxPayload+1)
+
+	tests := []struct {
+		name    string
+		frame   []byte
+		opcode  byte
+		payload []byte
+		fail    bool
+	}{
+		{"text", clientFrame(websocketOpText, []byte("hello")), websocketOpText, []byte("hello"), false},
+		{"empty close", clientFrame(websocketOpClose, nil), websocketOpClose, []byte{}, false},
+		{"ping", clientFrame(websocketOpPing, []byte{1, 2, 3}), websocketOpPing, []byte{1, 2, 3}, false},
+		{"16 bit length", clientFrame(websocketOpText, medium), websocketOpText, medium, false},
+		{"64 bit length over the limit", clientFrame(websocketOpText, large), 0, nil, true},
+		{"oversized header", oversized, 0, nil, true},
+		{"unmasked", []byte{0x81, 0x02, 'h', 'i'}, 0, nil, true},
+		{"truncated header", []byte{0x81}, 0, nil, true},
+		{"truncated length", []byte{0x81, 0x80 | 126, 0x01}, 0, nil, true},
+		{"truncated mask", []byte{0x81, 0x82, 0x01, 0x02}, 0, nil, true},
+		{"truncated payload", clientFrame(websocketOpText, []byte("hello"))[:8], 0, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ws := &websocketConn{reader: bufio.NewReader(bytes.NewReader(test.frame))}
+
+			opcode, payload, err := ws.readFrame()
+			if test.fail {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if opcode != test.opcode || !bytes.Equal(payload, test.payload) {
+				t.Fatalf("got opcode %#x payload %q, want %#x %q", opcode, payload, test.opcode, test.payload)
+			}
+		})
+	}
+}
+
+func TestWriteFrame(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		header []byte
+	}{
+		{"short", 5, []byte{0x81, 5}},
+		{"largest short", 125, []byte{0x81, 125}},
+		{"16 bit length", 126, []byte{0x81, 126, 0x00, 126}},
+		{"largest 16 bit length", 0xffff, []byte{0x81, 126, 0xff, 0xff}},
+		{"64 bit length", 0x10000, []byte{0x81, 127, 0, 0, 0, 0, 0, 1, 0, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, client := net.Pipe()
+			defer server.Close()
+			defer client.Close()
+
+			payload := bytes.Repeat([]byte("x"), test.size)
+			ws := &websocketConn{conn: server}
+
+			go ws.writeFrame(websocketOpText, payload)
+
+			frame := make([]byte, len(test.header)+test.size)
+			if _, err := io.ReadFull(client, frame); err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(frame[:len(test.header)], test.header) {
+				t.Fatalf("header = % x, want % x", frame[:len(test.header)], test.header)
+			}
+
+			if !bytes.Equal(frame[len(test.header):], payload) {
+				t.Fatal("payload mismatch")
+			}
+		})
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		want   bool
+	}{
+		{"no origin", "fox.local:8080", "", true},
+		{"same host", "fox.local:8080", "http://fox.local:8080", true},
+		{"case", "Fox.Local:8080", "http://fox.local:8080", true},
+		{"other port", "fox.local:8080", "http://fox.local:9090", false},
+		{"other host", "fox.local:8080", "http://evil.example", false},
+		{"null", "fox.local:8080", "null", false},
+		{"unparseable", "fox.local:8080", "http://%zz", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &http.Request{Host: test.host, Header: http.Header{}}
+			if test.origin != "" {
+				r.Header.Set("Origin", test.origin)
+			}
+
+			if got := sameOrigin(r); got != test.want {
+				t.Fatalf("sameOrigin = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -7,17 +7,18 @@ replace github.com/hairlesshobo/go-jack => ../go-jack
 require (
 	code.rocketnine.space/tslocum/cview v1.5.9
 	github.com/gdamore/tcell/v2 v2.7.4
+	github.com/go-audio/audio v1.0.0
+	github.com/go-audio/wav v1.1.0
 	github.com/hairlesshobo/go-jack v0.0.0-20240927205056-31f51b30b785
 	github.com/spf13/cobra v1.8.1
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
 	code.rocketnine.space/tslocum/cbind v0.1.5 // indirect
 	github.com/gdamore/encoding v1.0.0 // indirect
-	github.com/go-audio/audio v1.0.0 // indirect
 	github.com/go-audio/riff v1.0.0 // indirect
 	github.com/go-audio/transforms v0.0.0-20180121090939-51830ccc35a5 // indirect
-	github.com/go-audio/wav v1.1.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-runewidth v0.0.15 // indirect
@@ -26,5 +27,4 @@ require (
 	golang.org/x/sys v0.17.0 // indirect
 	golang.org/x/term v0.17.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
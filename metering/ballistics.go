@@ -0,0 +1,105 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package metering
+
+import (
+	"math"
+
+	"fox-audio/model"
+)
+
+const (
+	// quasi-peak integration time constants and release rates per IEC 60268-10
+	nordicAttackMs  = 1.7
+	nordicReleaseDb = 20.0 / 1.7 // 20 dB in 1.7 s
+	bbcAttackMs     = 2.5
+	bbcReleaseDb    = 24.0 / 2.8 // 24 dB in 2.8 s
+
+	// 99% of the final reading after 300 ms
+	vuIntegrationMs = 300.0 / 4.6
+
+	// scales the rectified average so a sine wave reads its RMS level
+	vuSineScale = math.Pi / (2 * math.Sqrt2)
+)
+
+// ballistics turns rectified samples into the reading of a meter standard
+type ballistics struct {
+	standard string
+	attack   float64 // one pole coefficient for ppm attack and vu integration
+	release  float64 // per sample multiplier for digital and ppm release
+
+	reading float64
+}
+
+func newBallistics(sampleRate int, options *model.MeteringOptions) ballistics {
+	b := ballistics{
+		standard: options.Standard,
+	}
+
+	fs := float64(sampleRate)
+
+	switch options.Standard {
+	case model.MeterStandardPpmNordic:
+		b.attack = onePole(nordicAttackMs, fs)
+		b.release = dbPerSample(nordicReleaseDb, fs)
+	case model.MeterStandardPpmBbc:
+		b.attack = onePole(bbcAttackMs, fs)
+		b.release = dbPerSample(bbcReleaseDb, fs)
+	case model.MeterStandardVu:
+		b.attack = onePole(vuIntegrationMs, fs)
+	default:
+		b.release = dbPerSample(options.FallRateDb, fs)
+	}
+
+	return b
+}
+
+// process feeds one rectified sample and returns the new reading
+func (b *ballistics) process(sample float64) float64 {
+	switch b.standard {
+	case model.MeterStandardPpmNordic, model.MeterStandardPpmBbc:
+		if sample > b.reading {
+			b.reading += (sample - b.reading) * b.attack
+		} else {
+			b.reading *= b.release
+		}
+	case model.MeterStandardVu:
+		b.reading += (sample*vuSineScale - b.reading) * b.attack
+	default:
+		if sample > b.reading {
+			b.reading = sample
+		} else {
+			b.reading *= b.release
+		}
+	}
+
+	return b.reading
+}
+
+func onePole(timeConstantMs float64, fs float64) float64 {
+	return 1 - math.Exp(-1/(timeConstantMs/1000*fs))
+}
+
+func dbPerSample(dbPerSecond float64, fs float64) float64 {
+	return math.Pow(10, -dbPerSecond/(20*fs))
+}
@@ -0,0 +1,453 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package metering
+
+import (
+	"math"
+	"sync"
+
+	"fox-audio/model"
+)
+
+// loudness measurement according to ITU-R BS.1770-4 with the EBU R128 /
+// Tech 3341 momentary, short-term and integrated windows and the
+// Tech 3342 loudness range
+
+const (
+	loudnessSubBlockMs     = 100
+	loudnessMomentaryBlock = 4  // 400 ms
+	loudnessShortTermBlock = 30 // 3 s
+
+	loudnessAbsoluteGate   = -70.0
+	loudnessRelativeGate   = -10.0
+	loudnessRangeGate      = -20.0
+	loudnessRangeLowPct    = 0.10
+	loudnessRangeHighPct   = 0.95
+	loudnessHistogramMax   = 10.0
+	loudnessHistogramSteps = 10 // bins per LU
+
+	truePeakOversample = 4
+	truePeakTaps       = 12
+)
+
+// polyphase interpolation filter from BS.1770-4 annex 2
+var truePeakCoefficients = [truePeakOversample][truePeakTaps]float64{
+	{0.0017089843750, 0.0109863281250, -0.0196533203125, 0.0332031250000, -0.0594482421875, 0.1373291015625,
+		0.9721679687500, -0.1022949218750, 0.0476074218750, -0.0266113281250, 0.0148925781250, -0.0083007812500},
+	{-0.0291748046875, 0.0292968750000, -0.0517578125000, 0.0891113281250, -0.1665039062500, 0.4650878906250,
+		0.7797851562500, -0.2003173828125, 0.1015625000000, -0.0582275390625, 0.0330810546875, -0.0189208984375},
+	{-0.0189208984375, 0.0330810546875, -0.0582275390625, 0.1015625000000, -0.2003173828125, 0.7797851562500,
+		0.4650878906250, -0.1665039062500, 0.0891113281250, -0.0517578125000, 0.0292968750000, -0.0291748046875},
+	{-0.0083007812500, 0.0148925781250, -0.0266113281250, 0.0476074218750, -0.1022949218750, 0.9721679687500,
+		0.1373291015625, -0.0594482421875, 0.0332031250000, -0.0196533203125, 0.0109863281250, 0.0017089843750},
+}
+
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+
+	return y
+}
+
+// kWeighting returns the shelving pre-filter and the RLB high-pass filter
+// for the given sample rate
+func kWeighting(sampleRate int) (biquad, biquad) {
+	fs := float64(sampleRate)
+
+	// stage 1: high shelf
+	f0 := 1681.974450955533
+	gain := 3.999843853973347
+	q := 0.7071752369554196
+
+	k := math.Tan(math.Pi * f0 / fs)
+	vh := math.Pow(10, gain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	// stage 2: high pass
+	f0 = 38.13547087602444
+	q = 0.5003270373238773
+
+	k = math.Tan(math.Pi * f0 / fs)
+	a0 = 1 + k/q + k*k
+
+	highPass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	return shelf, highPass
+}
+
+// LoudnessInput is a single channel feeding a loudness meter
+type LoudnessInput struct {
+	meter *LoudnessMeter
+
+	shelf    biquad
+	highPass biquad
+
+	sum      float64
+	position int
+
+	// completed sub-block energies waiting for the other channels, sized to
+	// hold every sub-block a single process cycle can complete
+	pending      []float64
+	pendingCount int
+
+	history      [truePeakTaps]float64
+	historyIndex int
+	truePeak     float64
+}
+
+// AddSample feeds a single sample into the loudness meter
+func (in *LoudnessInput) AddSample(sample float32) {
+	x := float64(sample)
+
+	y := in.highPass.process(in.shelf.process(x))
+	in.sum += y * y
+	in.position++
+
+	if in.position == in.meter.subBlockSize {
+		if in.pendingCount < len(in.pending) {
+			in.pending[in.pendingCount] = in.sum / float64(in.meter.subBlockSize)
+			in.pendingCount++
+		}
+
+		in.sum = 0
+		in.position = 0
+	}
+
+	// true peak
+	in.history[in.historyIndex] = x
+
+	for phase := range truePeakOversample {
+		value := 0.0
+
+		for tap := range truePeakTaps {
+			value += truePeakCoefficients[phase][tap] * in.history[(in.historyIndex+truePeakTaps-tap)%truePeakTaps]
+		}
+
+		value = math.Abs(value)
+
+		if value > in.truePeak {
+			in.truePeak = value
+		}
+	}
+
+	in.historyIndex = (in.historyIndex + 1) % truePeakTaps
+}
+
+// loudnessHistogram stores gating blocks in 0.1 LU bins so integrated
+// loudness and loudness range can be calculated for a recording of any length
+// with a fixed amount of memory
+type loudnessHistogram struct {
+	counts   []uint64
+	energies []float64
+}
+
+func newLoudnessHistogram() *loudnessHistogram {
+	bins := int((loudnessHistogramMax - loudnessAbsoluteGate) * loudnessHistogramSteps)
+
+	return &loudnessHistogram{
+		counts:   make([]uint64, bins),
+		energies: make([]float64, bins),
+	}
+}
+
+func (h *loudnessHistogram) add(energy float64) {
+	loudness := energyToLoudness(energy)
+
+	if loudness < loudnessAbsoluteGate {
+		return
+	}
+
+	bin := min(int((loudness-loudnessAbsoluteGate)*loudnessHistogramSteps), len(h.counts)-1)
+	h.counts[bin]++
+	h.energies[bin] += energy
+}
+
+func (h *loudnessHistogram) binLoudness(bin int) float64 {
+	return loudnessAbsoluteGate + float64(bin)/loudnessHistogramSteps
+}
+
+// gate returns the first bin above the relative gate calculated from all
+// blocks that passed the absolute gate
+func (h *loudnessHistogram) gate(relativeGate float64) (int, bool) {
+	count := uint64(0)
+	energy := 0.0
+
+	for bin := range h.counts {
+		count += h.counts[bin]
+		energy += h.energies[bin]
+	}
+
+	if count == 0 {
+		return 0, false
+	}
+
+	threshold := energyToLoudness(energy/float64(count)) + relativeGate
+	first := max(int(math.Ceil((threshold-loudnessAbsoluteGate)*loudnessHistogramSteps)), 0)
+
+	return first, true
+}
+
+func (h *loudnessHistogram) integrated() float64 {
+	first, ok := h.gate(loudnessRelativeGate)
+
+	if !ok {
+		return FloorDb
+	}
+
+	count := uint64(0)
+	energy := 0.0
+
+	for bin := first; bin < len(h.counts); bin++ {
+		count += h.counts[bin]
+		energy += h.energies[bin]
+	}
+
+	if count == 0 {
+		return FloorDb
+	}
+
+	return energyToLoudness(energy / float64(count))
+}
+
+func (h *loudnessHistogram) loudnessRange() float64 {
+	first, ok := h.gate(loudnessRangeGate)
+
+	if !ok {
+		return 0
+	}
+
+	total := uint64(0)
+	for bin := first; bin < len(h.counts); bin++ {
+		total += h.counts[bin]
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	low := h.percentile(first, total, loudnessRangeLowPct)
+	high := h.percentile(first, total, loudnessRangeHighPct)
+
+	return high - low
+}
+
+func (h *loudnessHistogram) percentile(first int, total uint64, pct float64) float64 {
+	target := uint64(math.Ceil(float64(total) * pct))
+	count := uint64(0)
+
+	for bin := first; bin < len(h.counts); bin++ {
+		count += h.counts[bin]
+
+		if count >= target {
+			return h.binLoudness(bin)
+		}
+	}
+
+	return h.binLoudness(len(h.counts) - 1)
+}
+
+// LoudnessMeter measures the loudness of a mono or stereo source. Samples are
+// fed to each input from the process thread and Commit is called once all
+// inputs have received the samples for the current cycle.
+type LoudnessMeter struct {
+	name         string
+	subBlockSize int
+	inputs       []*LoudnessInput
+
+	subBlocks      [loudnessShortTermBlock]float64
+	subBlockIndex  int
+	subBlockCount  int
+	integratedHist *loudnessHistogram
+	rangeHist      *loudnessHistogram
+
+	level model.LoudnessLevel
+	mutex sync.Mutex
+}
+
+func NewLoudnessMeter(name string, sampleRate int, framesPerPeriod int, channelCount int) *LoudnessMeter {
+	subBlockSize := sampleRate * loudnessSubBlockMs / 1000
+
+	meter := &LoudnessMeter{
+		name:           name,
+		subBlockSize:   subBlockSize,
+		inputs:         make([]*LoudnessInput, channelCount),
+		integratedHist: newLoudnessHistogram(),
+		rangeHist:      newLoudnessHistogram(),
+	}
+
+	for i := range meter.inputs {
+		shelf, highPass := kWeighting(sampleRate)
+
+		meter.inputs[i] = &LoudnessInput{
+			meter:    meter,
+			shelf:    shelf,
+			highPass: highPass,
+			pending:  make([]float64, framesPerPeriod/subBlockSize+1),
+		}
+	}
+
+	meter.level = model.LoudnessLevel{
+		Name:       name,
+		Momentary:  FloorDb,
+		ShortTerm:  FloorDb,
+		Integrated: FloorDb,
+		TruePeak:   FloorDb,
+	}
+
+	return meter
+}
+
+func (m *LoudnessMeter) Name() string {
+	return m.name
+}
+
+// Input returns the input for the given channel of the source
+func (m *LoudnessMeter) Input(channel int) *LoudnessInput {
+	return m.inputs[channel]
+}
+
+// Commit combines any sub-blocks completed by all inputs. When integrate is
+// false the momentary and short-term values are updated but the blocks are not
+// counted towards the integrated loudness and loudness range.
+func (m *LoudnessMeter) Commit(integrate bool) {
+	ready := len(m.inputs[0].pending)
+	for _, input := range m.inputs {
+		ready = min(ready, input.pendingCount)
+	}
+
+	if ready == 0 {
+		return
+	}
+
+	for block := range ready {
+		// channel weights are 1.0 for mono and stereo sources
+		energy := 0.0
+		for _, input := range m.inputs {
+			energy += input.pending[block]
+		}
+
+		m.subBlocks[m.subBlockIndex] = energy
+		m.subBlockIndex = (m.subBlockIndex + 1) % len(m.subBlocks)
+		m.subBlockCount++
+
+		if m.subBlockCount >= loudnessMomentaryBlock && integrate {
+			m.integratedHist.add(m.windowEnergy(loudnessMomentaryBlock))
+		}
+
+		if m.subBlockCount >= loudnessShortTermBlock && integrate {
+			m.rangeHist.add(m.windowEnergy(loudnessShortTermBlock))
+		}
+	}
+
+	for _, input := range m.inputs {
+		copy(input.pending, input.pending[ready:input.pendingCount])
+		input.pendingCount -= ready
+	}
+
+	m.publish(integrate)
+}
+
+// Level returns a copy of the most recent loudness values
+func (m *LoudnessMeter) Level() model.LoudnessLevel {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.level
+}
+
+func (m *LoudnessMeter) publish(integrate bool) {
+	level := model.LoudnessLevel{
+		Name:       m.name,
+		Momentary:  FloorDb,
+		ShortTerm:  FloorDb,
+		Integrated: m.integratedHist.integrated(),
+		Range:      m.rangeHist.loudnessRange(),
+		TruePeak:   FloorDb,
+	}
+
+	if m.subBlockCount >= loudnessMomentaryBlock {
+		level.Momentary = max(energyToLoudness(m.windowEnergy(loudnessMomentaryBlock)), FloorDb)
+	}
+
+	if m.subBlockCount >= loudnessShortTermBlock {
+		level.ShortTerm = max(energyToLoudness(m.windowEnergy(loudnessShortTermBlock)), FloorDb)
+	}
+
+	truePeak := 0.0
+	for _, input := range m.inputs {
+		truePeak = max(truePeak, input.truePeak)
+
+		// outside of recording the true peak only reflects the current block
+		if !integrate {
+			input.truePeak = 0
+		}
+	}
+
+	if truePeak > 0 {
+		level.TruePeak = max(20*math.Log10(truePeak), FloorDb)
+	}
+
+	m.mutex.Lock()
+	m.level = level
+	m.mutex.Unlock()
+}
+
+// windowEnergy averages the energy of the most recent sub-blocks
+func (m *LoudnessMeter) windowEnergy(blocks int) float64 {
+	energy := 0.0
+
+	for i := 1; i <= blocks; i++ {
+		energy += m.subBlocks[(m.subBlockIndex-i+len(m.subBlocks))%len(m.subBlocks)]
+	}
+
+	return energy / float64(blocks)
+}
+
+func energyToLoudness(energy float64) float64 {
+	if energy <= 0 {
+		return math.Inf(-1)
+	}
+
+	return -0.691 + 10*math.Log10(energy)
+}
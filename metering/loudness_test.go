@@ -0,0 +1,113 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package metering
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLoudnessMeterReference(t *testing.T) {
+	const (
+		sampleRate = 48000
+		seconds    = 20
+	)
+
+	// EBU Tech 3341: a stereo 1 kHz sine at -23 dBFS reads -23 LUFS
+	amplitude := math.Pow(10, -23.0/20)
+
+	tests := []struct {
+		name            string
+		framesPerPeriod int
+	}{
+		{"small period", 256},
+		{"default period", 4096},
+		{"one second period", 48000},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			meter := NewLoudnessMeter("test", sampleRate, test.framesPerPeriod, 2)
+
+			for frame := 0; frame < sampleRate*seconds; {
+				for i := 0; i < test.framesPerPeriod; i++ {
+					sample := float32(amplitude * math.Sin(2*math.Pi*1000*float64(frame+i)/sampleRate))
+
+					meter.Input(0).AddSample(sample)
+					meter.Input(1).AddSample(sample)
+				}
+
+				frame += test.framesPerPeriod
+				meter.Commit(true)
+			}
+
+			// every sub-block must count, however many a cycle completes
+			if expected := sampleRate * seconds / meter.subBlockSize; meter.subBlockCount < expected {
+				t.Errorf("%d sub-blocks measured, expected %d", meter.subBlockCount, expected)
+			}
+
+			level := meter.Level()
+
+			for _, value := range []struct {
+				name  string
+				value float64
+			}{
+				{"momentary", level.Momentary},
+				{"short-term", level.ShortTerm},
+				{"integrated", level.Integrated},
+			} {
+				if math.Abs(value.value+23) > 0.1 {
+					t.Errorf("%s loudness %.2f LUFS, expected -23.0", value.name, value.value)
+				}
+			}
+
+			if level.Range > 0.1 {
+				t.Errorf("loudness range %.2f LU, expected 0", level.Range)
+			}
+
+			// a sine at -23 dBFS peaks at -23 dBTP, allow for the
+			// interpolation filter ripple
+			if math.Abs(level.TruePeak+23) > 0.5 {
+				t.Errorf("true peak %.2f dBTP, expected -23.0", level.TruePeak)
+			}
+		})
+	}
+}
+
+func TestLoudnessMeterSilence(t *testing.T) {
+	meter := NewLoudnessMeter("test", 48000, 1024, 1)
+
+	for range 48000 * 5 / 1024 {
+		for range 1024 {
+			meter.Input(0).AddSample(0)
+		}
+
+		meter.Commit(true)
+	}
+
+	level := meter.Level()
+
+	if level.Integrated > FloorDb {
+		t.Errorf("integrated loudness %.2f LUFS for silence, expected the floor", level.Integrated)
+	}
+}
@@ -0,0 +1,206 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package metering
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"fox-audio/model"
+	"fox-audio/util"
+)
+
+const (
+	// FloorDb is the level reported for silence, matching the floor used by the meters
+	FloorDb = -150.0
+
+	defaultRmsWindowMs   = 300
+	defaultPeakHoldMs    = 1500
+	defaultPeakFallDbSec = 20.0
+
+	// samples at or above this amplitude count towards a run of full scale samples
+	fullScale = 0.99999
+)
+
+// PortMeter computes the absolute sample peak, the RMS over a sliding window
+// and a decaying peak hold for a single port, and detects clipping. It is fed
+// from the process thread, so nothing here allocates once the meter has been
+// created.
+type PortMeter struct {
+	sampleRate int
+
+	holdSamples       int
+	fallDbPerSample   float64
+	windowSum         float64
+	window            []float32
+	windowIndex       int
+	samplesSinceLevel int
+
+	blockPeak float32
+	holdLevel float64
+	holdAge   int
+
+	ballistics   ballistics
+	blockReading float64
+
+	// a clip event is either clipSamples consecutive full scale samples or
+	// any sample above overThreshold
+	clipSamples   int
+	overThreshold float32
+	clipRun       int
+	clipping      bool
+	clipStarted   bool
+	clipCount     atomic.Uint64
+	lastClipTime  atomic.Int64
+}
+
+func NewPortMeter(sampleRate int, options *model.MeteringOptions) *PortMeter {
+	windowSize := max(sampleRate*defaultRmsWindowMs/1000, 1)
+
+	return &PortMeter{
+		sampleRate:      sampleRate,
+		holdSamples:     sampleRate * defaultPeakHoldMs / 1000,
+		fallDbPerSample: defaultPeakFallDbSec / float64(sampleRate),
+		window:          make([]float32, windowSize),
+		holdLevel:       FloorDb,
+		ballistics:      newBallistics(sampleRate, options),
+		clipSamples:     options.ClipSamples,
+		overThreshold:   float32(math.Pow(10, options.OverThresholdDb/20)),
+	}
+}
+
+// AddSample feeds a single sample into the meter
+func (m *PortMeter) AddSample(sample float32) {
+	if sample < 0 {
+		sample = -sample
+	}
+
+	if sample > m.blockPeak {
+		m.blockPeak = sample
+	}
+
+	if reading := m.ballistics.process(float64(sample)); reading > m.blockReading {
+		m.blockReading = reading
+	}
+
+	clip := sample > m.overThreshold
+
+	if sample >= fullScale {
+		m.clipRun++
+		clip = clip || m.clipRun >= m.clipSamples
+	} else {
+		m.clipRun = 0
+	}
+
+	// a run of clipped samples is a single event
+	if clip && !m.clipping {
+		m.clipping = true
+		m.clipStarted = true
+		m.clipCount.Add(1)
+	} else if m.clipRun == 0 && !clip {
+		m.clipping = false
+	}
+
+	square := sample * sample
+	m.windowSum += float64(square) - float64(m.window[m.windowIndex])
+	m.window[m.windowIndex] = square
+	m.windowIndex++
+
+	if m.windowIndex == len(m.window) {
+		m.windowIndex = 0
+
+		// recompute the running sum once per window so rounding errors can't accumulate
+		m.windowSum = 0
+		for _, value := range m.window {
+			m.windowSum += float64(value)
+		}
+	}
+
+	m.samplesSinceLevel++
+}
+
+// Level returns the levels for all samples added since the last call and
+// advances the peak hold by the same number of samples
+func (m *PortMeter) Level() model.SignalLevel {
+	peak := util.AmplitudeToDbFloor(m.blockPeak, FloorDb)
+	reading := util.AmplitudeToDbFloor(float32(m.blockReading), FloorDb)
+
+	rms := FloorDb
+	if m.windowSum > 0 {
+		rms = util.AmplitudeToDbFloor(float32(math.Sqrt(m.windowSum/float64(len(m.window)))), FloorDb)
+	}
+
+	if reading >= m.holdLevel {
+		m.holdLevel = reading
+		m.holdAge = 0
+	} else {
+		m.holdAge += m.samplesSinceLevel
+
+		if m.holdAge > m.holdSamples {
+			fallSamples := min(m.holdAge-m.holdSamples, m.samplesSinceLevel)
+			m.holdLevel = max(m.holdLevel-m.fallDbPerSample*float64(fallSamples), reading)
+		}
+	}
+
+	clipped := m.clipStarted
+	if clipped {
+		m.lastClipTime.Store(time.Now().UnixMilli())
+	}
+
+	m.blockPeak = 0
+	m.blockReading = m.ballistics.reading
+	m.samplesSinceLevel = 0
+	m.clipStarted = false
+
+	return model.SignalLevel{
+		Instant:   rms,
+		Max:       peak,
+		Peak:      m.holdLevel,
+		Meter:     reading,
+		Clipped:   clipped,
+		ClipCount: m.clipCount.Load(),
+	}
+}
+
+// ClipEvents returns the number of clip events and the time of the most
+// recent one. Safe to call from outside the process thread.
+func (m *PortMeter) ClipEvents() (uint64, time.Time) {
+	return m.clipCount.Load(), time.UnixMilli(m.lastClipTime.Load())
+}
+
+// Reset clears the window and peak hold
+func (m *PortMeter) Reset() {
+	clear(m.window)
+	m.windowSum = 0
+	m.windowIndex = 0
+	m.samplesSinceLevel = 0
+	m.blockPeak = 0
+	m.holdLevel = FloorDb
+	m.holdAge = 0
+	m.ballistics.reading = 0
+	m.blockReading = 0
+	m.clipRun = 0
+	m.clipping = false
+	m.clipStarted = false
+}
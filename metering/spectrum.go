@@ -0,0 +1,236 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package metering
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fox-audio/model"
+)
+
+const (
+	spectrumFftSize      = 8192
+	spectrumPeakHoldMs   = 1000
+	spectrumPeakFallDb   = 20.0 // per second
+	spectrumBufferPeriod = 4    // fft sizes of audio buffered between the process thread and the analyzer
+)
+
+// ISO 266 1/3 octave center frequencies
+var thirdOctaveCenters = []float64{
+	20, 25, 31.5, 40, 50, 63, 80, 100, 125, 160,
+	200, 250, 315, 400, 500, 630, 800, 1000, 1250, 1600,
+	2000, 2500, 3150, 4000, 5000, 6300, 8000, 10000, 12500, 16000,
+	20000,
+}
+
+type spectrumBand struct {
+	center  float64
+	lowBin  int
+	highBin int
+
+	peak    float64
+	peakAge time.Duration
+}
+
+// SpectrumAnalyzer shows the 1/3 octave spectrum of a single port. Samples
+// are copied off the process thread through a buffered channel and the FFT is
+// done by whoever calls Analyze.
+type SpectrumAnalyzer struct {
+	sampleRate int
+	port       atomic.Int32
+
+	samples chan float32
+
+	history      []float64
+	historyIndex int
+	window       []float64
+	windowPower  float64
+	fftBuffer    []complex128
+	bands        []spectrumBand
+	lastAnalyze  time.Time
+
+	// Analyze and Reset are called from different goroutines
+	mutex sync.Mutex
+}
+
+func NewSpectrumAnalyzer(sampleRate int) *SpectrumAnalyzer {
+	a := &SpectrumAnalyzer{
+		sampleRate: sampleRate,
+		samples:    make(chan float32, spectrumFftSize*spectrumBufferPeriod),
+		history:    make([]float64, spectrumFftSize),
+		window:     make([]float64, spectrumFftSize),
+		fftBuffer:  make([]complex128, spectrumFftSize),
+		bands:      make([]spectrumBand, 0, len(thirdOctaveCenters)),
+	}
+
+	a.port.Store(-1)
+
+	// hann window
+	for i := range a.window {
+		a.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(spectrumFftSize-1))
+		a.windowPower += a.window[i] * a.window[i]
+	}
+
+	nyquist := float64(sampleRate) / 2
+	binWidth := float64(sampleRate) / spectrumFftSize
+	bandEdge := math.Pow(2, 1.0/6)
+
+	for _, center := range thirdOctaveCenters {
+		if center >= nyquist {
+			break
+		}
+
+		// bands narrower than a bin use the bin nearest to the center
+		lowBin := int(math.Ceil(center / bandEdge / binWidth))
+		highBin := int(math.Floor(min(center*bandEdge, nyquist) / binWidth))
+		if highBin < lowBin {
+			lowBin = int(math.Round(center / binWidth))
+			highBin = lowBin
+		}
+
+		a.bands = append(a.bands, spectrumBand{
+			center:  center,
+			lowBin:  max(lowBin, 1),
+			highBin: min(highBin, spectrumFftSize/2),
+			peak:    FloorDb,
+		})
+	}
+
+	return a
+}
+
+// Port returns the index of the analyzed port, or -1 when disabled
+func (a *SpectrumAnalyzer) Port() int {
+	return int(a.port.Load())
+}
+
+// SetPort selects the port to analyze, -1 disables the analyzer
+func (a *SpectrumAnalyzer) SetPort(port int) {
+	a.port.Store(int32(port))
+}
+
+// HasSpace returns true if count samples can be added without blocking
+func (a *SpectrumAnalyzer) HasSpace(count int) bool {
+	return len(a.samples)+count <= cap(a.samples)
+}
+
+// AddSample copies a sample from the process thread. Check HasSpace first.
+func (a *SpectrumAnalyzer) AddSample(sample float32) {
+	a.samples <- sample
+}
+
+// Analyze consumes the copied audio and returns the current spectrum
+func (a *SpectrumAnalyzer) Analyze() *model.Spectrum {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+out:
+	for {
+		select {
+		case sample := <-a.samples:
+			a.history[a.historyIndex] = float64(sample)
+			a.historyIndex = (a.historyIndex + 1) % len(a.history)
+		default:
+			break out
+		}
+	}
+
+	for i := range a.fftBuffer {
+		a.fftBuffer[i] = complex(a.history[(a.historyIndex+i)%len(a.history)]*a.window[i], 0)
+	}
+
+	fft(a.fftBuffer)
+
+	now := time.Now()
+	elapsed := now.Sub(a.lastAnalyze)
+	if a.lastAnalyze.IsZero() {
+		elapsed = 0
+	}
+	a.lastAnalyze = now
+
+	spectrum := &model.Spectrum{
+		Port:  a.Port(),
+		Bands: make([]model.SpectrumBand, len(a.bands)),
+	}
+
+	for i := range a.bands {
+		band := &a.bands[i]
+
+		power := 0.0
+		for bin := band.lowBin; bin <= band.highBin; bin++ {
+			power += real(a.fftBuffer[bin])*real(a.fftBuffer[bin]) + imag(a.fftBuffer[bin])*imag(a.fftBuffer[bin])
+		}
+
+		// mean square of the band, relative to a full scale sine
+		meanSquare := 2 * power / (spectrumFftSize * a.windowPower)
+		level := FloorDb
+		if meanSquare > 0 {
+			level = max(10*math.Log10(meanSquare/0.5), FloorDb)
+		}
+
+		if level >= band.peak {
+			band.peak = level
+			band.peakAge = 0
+		} else {
+			band.peakAge += elapsed
+
+			if band.peakAge > spectrumPeakHoldMs*time.Millisecond {
+				band.peak = max(band.peak-spectrumPeakFallDb*elapsed.Seconds(), level)
+			}
+		}
+
+		spectrum.Bands[i] = model.SpectrumBand{
+			Frequency: band.center,
+			Level:     level,
+			Peak:      band.peak,
+		}
+	}
+
+	return spectrum
+}
+
+// Reset clears the analyzed audio and peaks, used when the port changes
+func (a *SpectrumAnalyzer) Reset() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+out:
+	for {
+		select {
+		case <-a.samples:
+		default:
+			break out
+		}
+	}
+
+	clear(a.history)
+	a.historyIndex = 0
+
+	for i := range a.bands {
+		a.bands[i].peak = FloorDb
+		a.bands[i].peakAge = 0
+	}
+}
@@ -0,0 +1,107 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package metering
+
+import (
+	"math"
+	"sync"
+
+	"fox-audio/model"
+)
+
+const (
+	stereoIntegrationMs = 300.0
+
+	// limits the balance reading when one side is silent
+	maxBalanceDb = 60.0
+)
+
+// StereoMeter computes the phase correlation and L/R balance of a pair of
+// ports using exponentially averaged products of the two signals
+type StereoMeter struct {
+	name  string
+	ports []int
+
+	coefficient float64
+	leftRight   float64
+	leftLeft    float64
+	rightRight  float64
+
+	level model.StereoLevel
+	mutex sync.Mutex
+}
+
+func NewStereoMeter(name string, ports []int, sampleRate int) *StereoMeter {
+	return &StereoMeter{
+		name:        name,
+		ports:       ports,
+		coefficient: onePole(stereoIntegrationMs, float64(sampleRate)),
+		level: model.StereoLevel{
+			Name:  name,
+			Ports: ports,
+		},
+	}
+}
+
+// Ports returns the indexes of the left and right ports
+func (m *StereoMeter) Ports() []int {
+	return m.ports
+}
+
+// AddFrame feeds one sample from each side of the pair
+func (m *StereoMeter) AddFrame(left float32, right float32) {
+	l := float64(left)
+	r := float64(right)
+
+	m.leftRight += (l*r - m.leftRight) * m.coefficient
+	m.leftLeft += (l*l - m.leftLeft) * m.coefficient
+	m.rightRight += (r*r - m.rightRight) * m.coefficient
+}
+
+// Commit publishes the current readings, called once per process cycle
+func (m *StereoMeter) Commit() {
+	correlation := 0.0
+	if energy := math.Sqrt(m.leftLeft * m.rightRight); energy > 1e-12 {
+		correlation = max(min(m.leftRight/energy, 1), -1)
+	}
+
+	// positive when the right side is louder
+	balance := 0.0
+	if m.leftLeft > 1e-12 || m.rightRight > 1e-12 {
+		balance = 10 * math.Log10((m.rightRight+1e-15)/(m.leftLeft+1e-15))
+		balance = max(min(balance, maxBalanceDb), -maxBalanceDb)
+	}
+
+	m.mutex.Lock()
+	m.level.Correlation = correlation
+	m.level.Balance = balance
+	m.mutex.Unlock()
+}
+
+// Level returns a copy of the most recent readings
+func (m *StereoMeter) Level() model.StereoLevel {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.level
+}
@@ -27,7 +27,7 @@ type OutputType int
 const (
 	OutputTUI OutputType = iota
 	OutputJSON
-	// OutputText
+	OutputText
 )
 
 type CommandLineArgs struct {
@@ -38,6 +38,13 @@ type CommandLineArgs struct {
 	ProfileName string
 	ConfigFile  string
 	OutputType  string
+
+	Event  string
+	Venue  string
+	Tokens map[string]string
+
+	ApiListen string
+	OscListen string
 }
 
 type Config struct {
@@ -50,6 +57,49 @@ type Config struct {
 	OutputType                   OutputType `yaml:"output_Type,omitempty"`
 
 	SimulationOptions *SimulationOptions `yaml:"simulation_options"`
+	MeteringOptions   *MeteringOptions   `yaml:"metering"`
+	Theme             *ThemeOptions      `yaml:"theme"`
+	Api               *ApiOptions        `yaml:"api"`
+	Osc               *OscOptions        `yaml:"osc"`
+}
+
+type MeteringOptions struct {
+	Standard         string  `yaml:"standard,omitempty"`
+	FallRateDb       float64 `yaml:"fall_rate_db,omitempty"`
+	ReferenceLevelDb float64 `yaml:"reference_level_db,omitempty"`
+	ClipSamples      int     `yaml:"clip_samples,omitempty"`
+	OverThresholdDb  float64 `yaml:"over_threshold_db,omitempty"`
+}
+
+type ThemeOptions struct {
+	Name     string                  `yaml:"name,omitempty"`
+	Palettes map[string]ThemePalette `yaml:"palettes,omitempty"`
+}
+
+// ThemePalette is a custom palette, colors that aren't set are taken from the
+// built-in base palette
+type ThemePalette struct {
+	Base   string            `yaml:"base,omitempty"`
+	Colors map[string]string `yaml:"colors,omitempty"`
+}
+
+// ApiOptions configures the HTTP control API, which is disabled while Listen
+// is empty
+type ApiOptions struct {
+	Listen         string `yaml:"listen,omitempty"`
+	PushIntervalMs int    `yaml:"push_interval_ms,omitempty"`
+
+	// required on every /api request when set, as a bearer token or the
+	// token query parameter
+	Token string `yaml:"token,omitempty"`
+}
+
+// OscOptions configures OSC control and feedback, which are disabled while
+// Listen and Feedback are empty
+type OscOptions struct {
+	Listen             string   `yaml:"listen,omitempty"`
+	Feedback           []string `yaml:"feedback,omitempty"`
+	FeedbackIntervalMs int      `yaml:"feedback_interval_ms,omitempty"`
 }
 
 type SimulationOptions struct {
@@ -22,10 +22,107 @@
 // =================================================================================
 package model
 
+const (
+	ErrorPolicyStopAll  = "stop_all"
+	ErrorPolicyContinue = "continue"
+
+	TakeSchemeLetters   = "letters"
+	TakeSchemeNumbers   = "numbers"
+	TakeSchemeTimestamp = "timestamp"
+
+	MeterStandardDigital   = "digital"
+	MeterStandardPpmNordic = "ppm_nordic"
+	MeterStandardPpmBbc    = "ppm_bbc"
+	MeterStandardVu        = "vu"
+
+	// Midas M32 consoles speak the same protocol
+	ConsoleTypeX32 = "x32"
+
+	TransportModeNone   = ""
+	TransportModeFollow = "follow"
+	TransportModeMaster = "master"
+
+	MidiTypeNote = "note"
+	MidiTypeCc   = "cc"
+
+	MidiActionRecord  = "record"
+	MidiActionPause   = "pause"
+	MidiActionStop    = "stop"
+	MidiActionNewTake = "new_take"
+	MidiActionMarker  = "marker"
+	MidiActionArm     = "arm"
+	MidiActionDisarm  = "disarm"
+
+	// device id that every MMC device answers to
+	MmcAllCall = 0x7f
+)
+
 var (
 	OutputTypeMap = map[string]OutputType{
 		"tui":  OutputTUI,
 		"json": OutputJSON,
-		// "text": OutputText,
+		"text": OutputText,
+	}
+
+	ErrorPolicies = []string{
+		ErrorPolicyStopAll,
+		ErrorPolicyContinue,
+	}
+
+	TakeSchemes = []string{
+		TakeSchemeLetters,
+		TakeSchemeNumbers,
+		TakeSchemeTimestamp,
+	}
+
+	ConsoleTypes = []string{
+		ConsoleTypeX32,
+	}
+
+	TransportModes = []string{
+		TransportModeNone,
+		TransportModeFollow,
+		TransportModeMaster,
+	}
+
+	MidiTypes = []string{
+		MidiTypeNote,
+		MidiTypeCc,
+	}
+
+	MidiActions = []string{
+		MidiActionRecord,
+		MidiActionPause,
+		MidiActionStop,
+		MidiActionNewTake,
+		MidiActionMarker,
+		MidiActionArm,
+		MidiActionDisarm,
+	}
+
+	// MMC command bytes by the names used in the profile
+	MmcCommands = map[string]byte{
+		"stop":          0x01,
+		"play":          0x02,
+		"deferred_play": 0x03,
+		"record_strobe": 0x06,
+		"record_exit":   0x07,
+		"record_pause":  0x08,
+		"pause":         0x09,
+		"locate":        0x44,
+	}
+
+	MeterStandards = []string{
+		MeterStandardDigital,
+		MeterStandardPpmNordic,
+		MeterStandardPpmBbc,
+		MeterStandardVu,
+	}
+
+	FileHealthNames = map[FileHealth]string{
+		FileHealthOK:       "ok",
+		FileHealthRetrying: "retrying",
+		FileHealthFailed:   "failed",
+		FileHealthDisabled: "disabled",
 	}
 )
@@ -0,0 +1,72 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package model
+
+import (
+	"time"
+)
+
+type Manifest struct {
+	Version         int              `json:"version"`
+	ProfileName     string           `json:"profile_name"`
+	Take            string           `json:"take"`
+	TakeNumber      int              `json:"take_number"`
+	Directory       string           `json:"directory"`
+	SampleRate      int              `json:"sample_rate"`
+	FramesPerPeriod int              `json:"frames_per_period"`
+	BitDepth        int              `json:"bit_depth"`
+	StartTime       time.Time        `json:"start_time"`
+	StopTime        *time.Time       `json:"stop_time,omitempty"`
+	UpdatedTime     time.Time        `json:"updated_time"`
+	Finalized       bool             `json:"finalized"`
+	XrunCount       int              `json:"xrun_count"`
+	Errors          []ManifestError  `json:"errors"`
+	Markers         []ManifestMarker `json:"markers"`
+	Files           []ManifestFile   `json:"files"`
+	Profile         *Profile         `json:"profile"`
+}
+
+type ManifestFile struct {
+	ChannelName string   `json:"channel_name"`
+	FileName    string   `json:"file_name"`
+	Ports       []string `json:"ports"`
+	Health      string   `json:"health"`
+	Error       string   `json:"error,omitempty"`
+	Bytes       uint64   `json:"bytes"`
+	Samples     uint64   `json:"samples"`
+	PeakDbfs    float64  `json:"peak_dbfs"`
+	Sha256      string   `json:"sha256,omitempty"`
+	AudioSha256 string   `json:"audio_sha256,omitempty"`
+	AudioMd5    string   `json:"audio_md5,omitempty"`
+}
+
+type ManifestError struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+type ManifestMarker struct {
+	Time  time.Time `json:"time"`
+	Frame uint64    `json:"frame"`
+	Name  string    `json:"name"`
+}
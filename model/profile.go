@@ -22,11 +22,19 @@
 // =================================================================================
 package model
 
+import (
+	"time"
+)
+
 type Profile struct {
 	Name        string             `yaml:"name"`
 	AudioServer ProfileAudioServer `yaml:"audio_server"`
 	Output      ProfileOutput      `yaml:"output"`
 	Channels    []ProfileChannel   `yaml:"channels"`
+	Loudness    []ProfileLoudness  `yaml:"loudness"`
+	Tokens      map[string]string  `yaml:"tokens"`
+	Console     ProfileConsole     `yaml:"console"`
+	Midi        ProfileMidi        `yaml:"midi"`
 }
 
 type ProfileAudioServer struct {
@@ -34,23 +42,74 @@ type ProfileAudioServer struct {
 	Interface       string `yaml:"interface"`
 	SampleRate      int    `yaml:"sample_rate"`
 	FramesPerPeriod int    `yaml:"frames_per_period"`
+	Transport       string `yaml:"transport"`
 }
 
 type ProfileChannel struct {
 	Ports       []int  `yaml:"ports"`
 	ChannelName string `yaml:"channel_name"`
 	Disabled    bool   `yaml:"disabled"`
+
+	// console channel the name is imported from, the first port when not set
+	ConsoleChannel int `yaml:"console_channel"`
+}
+
+// ProfileConsole names the channels after the console they are recorded from,
+// nothing is imported while Address is empty
+type ProfileConsole struct {
+	Type           string `yaml:"type"`
+	Address        string `yaml:"address"`
+	TimeoutMs      int    `yaml:"timeout_ms"`
+	DisableUnnamed bool   `yaml:"disable_unnamed"`
+	DisableMuted   bool   `yaml:"disable_muted"`
+}
+
+// ProfileLoudness attaches a loudness meter to either a profile channel or a
+// list of input ports
+type ProfileLoudness struct {
+	Name    string `yaml:"name"`
+	Channel string `yaml:"channel"`
+	Ports   []int  `yaml:"ports"`
+}
+
+// ProfileMidi maps MIDI Machine Control commands and note or CC messages
+// received on the MIDI input port to actions
+type ProfileMidi struct {
+	Enable      bool                 `yaml:"enable"`
+	Connect     []string             `yaml:"connect"`
+	MmcDeviceId int                  `yaml:"mmc_device_id"`
+	Mmc         map[string]string    `yaml:"mmc"`
+	Mappings    []ProfileMidiMapping `yaml:"mappings"`
+}
+
+type ProfileMidiMapping struct {
+	Type     string `yaml:"type"`
+	Channel  int    `yaml:"channel"`
+	Number   int    `yaml:"number"`
+	Action   string `yaml:"action"`
+	Argument string `yaml:"argument"`
 }
 
 type ProfileOutput struct {
-	DirectoryTemplate string  `yaml:"directory_template"`
-	BufferSizeSeconds float64 `yaml:"buffer_size_seconds"`
-	MinimumWriteSize  float64 `yaml:"minimum_write_size"`
-	Format            string  `yaml:"format"`
-	BitDepth          int     `yaml:"bit_depth"`
+	DirectoryTemplate   string  `yaml:"directory_template"`
+	FilenameTemplate    string  `yaml:"filename_template"`
+	TakeScheme          string  `yaml:"take_scheme"`
+	TakeDigits          int     `yaml:"take_digits"`
+	TakeTimestampFormat string  `yaml:"take_timestamp_format"`
+	BufferSizeSeconds   float64 `yaml:"buffer_size_seconds"`
+	MinimumWriteSize    float64 `yaml:"minimum_write_size"`
+	Format              string  `yaml:"format"`
+	BitDepth            int     `yaml:"bit_depth"`
+	ErrorPolicy         string  `yaml:"error_policy"`
+	WriteRetries        int     `yaml:"write_retries"`
+	WriteRetryDelayMs   int     `yaml:"write_retry_delay_ms"`
+	Checksum            bool    `yaml:"checksum"`
+	ChecksumMd5         bool    `yaml:"checksum_md5"`
 
 	// these are calculated at runtime and used internally, but
 	// not able to be set in the profile
-	Directory string
-	Take      string
+	Directory  string
+	Take       string
+	TakeNumber int
+	TakeTime   time.Time
 }
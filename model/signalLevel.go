@@ -22,8 +22,13 @@
 // =================================================================================
 package model
 
+// SignalLevel holds the meter levels of a single port in dBFS
 type SignalLevel struct {
-	Instant int
-	Max     int
-	Peak    int
+	Instant float64 // RMS over the metering window
+	Max     float64 // absolute sample peak of the last process cycle
+	Peak    float64 // decaying peak hold of the meter reading
+	Meter   float64 // reading with the ballistics of the selected meter standard
+
+	Clipped   bool   // a clip event started during the last process cycle
+	ClipCount uint64 // clip events since the meter was created
 }
@@ -22,8 +22,18 @@
 // =================================================================================
 package model
 
+type FileHealth int
+
+const (
+	FileHealthOK FileHealth = iota
+	FileHealthRetrying
+	FileHealthFailed
+	FileHealthDisabled
+)
+
 type UiOutputFile struct {
-	Ports []string
-	Name  string
-	Size  uint64
+	Ports  []string
+	Name   string
+	Size   uint64
+	Health FileHealth
 }
@@ -0,0 +1,341 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	bundleTag = "#bundle"
+)
+
+// Message is a single OSC message. Arguments are int32, float32, string,
+// []byte, int64, float64, bool or nil, which map to the OSC 1.0 type tags
+// i f s b h d T/F N.
+type Message struct {
+	Address   string
+	Arguments []any
+}
+
+func NewMessage(address string, arguments ...any) *Message {
+	return &Message{
+		Address:   address,
+		Arguments: arguments,
+	}
+}
+
+// Marshal encodes a message for sending in a single datagram
+func (m *Message) Marshal() ([]byte, error) {
+	var buffer bytes.Buffer
+	var data bytes.Buffer
+
+	typeTags := ","
+
+	for _, argument := range m.Arguments {
+		switch value := argument.(type) {
+		case int32:
+			typeTags += "i"
+			binary.Write(&data, binary.BigEndian, value)
+		case int:
+			typeTags += "i"
+			binary.Write(&data, binary.BigEndian, int32(value))
+		case float32:
+			typeTags += "f"
+			binary.Write(&data, binary.BigEndian, value)
+		case string:
+			typeTags += "s"
+			writePaddedString(&data, value)
+		case []byte:
+			typeTags += "b"
+			binary.Write(&data, binary.BigEndian, int32(len(value)))
+			data.Write(value)
+			data.Write(make([]byte, padding(len(value))))
+		case int64:
+			typeTags += "h"
+			binary.Write(&data, binary.BigEndian, value)
+		case float64:
+			typeTags += "d"
+			binary.Write(&data, binary.BigEndian, value)
+		case bool:
+			if value {
+				typeTags += "T"
+			} else {
+				typeTags += "F"
+			}
+		case nil:
+			typeTags += "N"
+		default:
+			return nil, errors.New("unsupported OSC argument type for " + m.Address)
+		}
+	}
+
+	writePaddedString(&buffer, m.Address)
+	writePaddedString(&buffer, typeTags)
+	buffer.Write(data.Bytes())
+
+	return buffer.Bytes(), nil
+}
+
+// String returns argument i as a string, numbers are formatted
+func (m *Message) String(i int) (string, bool) {
+	if i >= len(m.Arguments) {
+		return "", false
+	}
+
+	switch value := m.Arguments[i].(type) {
+	case string:
+		return value, true
+	case int32:
+		return strconv.Itoa(int(value)), true
+	case int64:
+		return strconv.FormatInt(value, 10), true
+	case float32:
+		return strconv.FormatFloat(float64(value), 'f', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	}
+
+	return "", false
+}
+
+// Float returns argument i as a number, booleans are 1 or 0 and strings are
+// parsed
+func (m *Message) Float(i int) (float64, bool) {
+	if i >= len(m.Arguments) {
+		return 0, false
+	}
+
+	switch value := m.Arguments[i].(type) {
+	case int32:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case float32:
+		return float64(value), true
+	case float64:
+		return value, true
+	case bool:
+		if value {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return number, err == nil
+	}
+
+	return 0, false
+}
+
+// Unmarshal decodes a datagram holding a message or a bundle. Bundles are
+// flattened and their time tags ignored, everything is handled immediately.
+func Unmarshal(packet []byte) ([]*Message, error) {
+	if len(packet) == 0 || len(packet)%4 != 0 {
+		return nil, errors.New("invalid OSC packet size: " + strconv.Itoa(len(packet)))
+	}
+
+	if packet[0] == '#' {
+		return unmarshalBundle(packet)
+	}
+
+	message, err := unmarshalMessage(packet)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*Message{message}, nil
+}
+
+//
+// private functions
+//
+
+func unmarshalBundle(packet []byte) ([]*Message, error) {
+	reader := bytes.NewReader(packet)
+
+	tag, err := readPaddedString(reader)
+	if err != nil || tag != bundleTag {
+		return nil, errors.New("invalid OSC bundle")
+	}
+
+	// time tag, seeking past the end doesn't fail so the length is checked
+	if reader.Len() < 8 {
+		return nil, errors.New("invalid OSC bundle")
+	}
+
+	if _, err := reader.Seek(8, io.SeekCurrent); err != nil {
+		return nil, errors.New("invalid OSC bundle")
+	}
+
+	messages := make([]*Message, 0)
+
+	for reader.Len() > 0 {
+		var size int32
+		if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+			return nil, errors.New("invalid OSC bundle element")
+		}
+
+		if size < 0 || int(size) > reader.Len() {
+			return nil, errors.New("invalid OSC bundle element size: " + strconv.Itoa(int(size)))
+		}
+
+		element := make([]byte, size)
+		reader.Read(element)
+
+		elementMessages, err := Unmarshal(element)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, elementMessages...)
+	}
+
+	return messages, nil
+}
+
+func unmarshalMessage(packet []byte) (*Message, error) {
+	reader := bytes.NewReader(packet)
+
+	address, err := readPaddedString(reader)
+	if err != nil || !strings.HasPrefix(address, "/") {
+		return nil, errors.New("invalid OSC address")
+	}
+
+	message := NewMessage(address)
+
+	// type tags are optional in very old implementations
+	if reader.Len() == 0 {
+		return message, nil
+	}
+
+	typeTags, err := readPaddedString(reader)
+	if err != nil || !strings.HasPrefix(typeTags, ",") {
+		return nil, errors.New("invalid OSC type tags for " + address)
+	}
+
+	for _, typeTag := range typeTags[1:] {
+		var argument any
+
+		switch typeTag {
+		case 'i':
+			var value int32
+			err = binary.Read(reader, binary.BigEndian, &value)
+			argument = value
+		case 'f':
+			var value float32
+			err = binary.Read(reader, binary.BigEndian, &value)
+			argument = value
+		case 's', 'S':
+			argument, err = readPaddedString(reader)
+		case 'b':
+			argument, err = readBlob(reader)
+		case 'h':
+			var value int64
+			err = binary.Read(reader, binary.BigEndian, &value)
+			argument = value
+		case 'd':
+			var value float64
+			err = binary.Read(reader, binary.BigEndian, &value)
+			argument = value
+		case 'T':
+			argument = true
+		case 'F':
+			argument = false
+		case 'N':
+			argument = nil
+		case 'I':
+			argument = math.Inf(1)
+		default:
+			return nil, errors.New("unsupported OSC type tag '" + string(typeTag) + "' for " + address)
+		}
+
+		if err != nil {
+			return nil, errors.New("truncated OSC message for " + address)
+		}
+
+		message.Arguments = append(message.Arguments, argument)
+	}
+
+	return message, nil
+}
+
+func readPaddedString(reader *bytes.Reader) (string, error) {
+	var value strings.Builder
+
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+
+		if b == 0 {
+			break
+		}
+
+		value.WriteByte(b)
+	}
+
+	// the terminator is part of the padded length
+	if _, err := reader.Seek(int64(padding(value.Len()+1)), io.SeekCurrent); err != nil {
+		return "", err
+	}
+
+	return value.String(), nil
+}
+
+func readBlob(reader *bytes.Reader) ([]byte, error) {
+	var size int32
+	if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+
+	if size < 0 || int(size) > reader.Len() {
+		return nil, errors.New("invalid blob size")
+	}
+
+	blob := make([]byte, size)
+	reader.Read(blob)
+
+	if _, err := reader.Seek(int64(padding(int(size))), io.SeekCurrent); err != nil {
+		return nil, err
+	}
+
+	return blob, nil
+}
+
+func writePaddedString(buffer *bytes.Buffer, value string) {
+	buffer.WriteString(value)
+	buffer.Write(make([]byte, 1+padding(len(value)+1)))
+}
+
+// padding is the number of zero bytes that bring size to a multiple of 4
+func padding(size int) int {
+	return (4 - size%4) % 4
+}
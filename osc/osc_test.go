@@ -0,0 +1,243 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	tests := []struct {
+		name      string
+		address   string
+		arguments []any
+	}{
+		{"no arguments", "/fox/record", nil},
+		{"int", "/fox/level", []any{int32(-42)}},
+		{"float", "/fox/level", []any{float32(0.75)}},
+		{"string padded to 4", "/fox/stop", []any{"abc"}},
+		{"string filling 4", "/fox/stop", []any{"abcd"}},
+		{"empty string", "/fox/marker", []any{""}},
+		{"blob", "/fox/blob", []any{[]byte{1, 2, 3, 4, 5}}},
+		{"int64 and float64", "/fox/wide", []any{int64(1) << 40, 1.5}},
+		{"bool and nil", "/fox/flags", []any{true, false, nil}},
+		{"mixed", "/ch/01/config/name", []any{"Kick", int32(1), float32(-3.5), []byte{}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			packet, err := NewMessage(test.address, test.arguments...).Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(packet)%4 != 0 {
+				t.Fatalf("packet size %d is not a multiple of 4", len(packet))
+			}
+
+			messages, err := Unmarshal(packet)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(messages) != 1 {
+				t.Fatalf("got %d messages, want 1", len(messages))
+			}
+
+			if messages[0].Address != test.address {
+				t.Errorf("address = %q, want %q", messages[0].Address, test.address)
+			}
+
+			if len(messages[0].Arguments) != len(test.arguments) {
+				t.Fatalf("got %d arguments, want %d", len(messages[0].Arguments), len(test.arguments))
+			}
+
+			for i, argument := range test.arguments {
+				if !reflect.DeepEqual(messages[0].Arguments[i], argument) {
+					t.Errorf("argument %d = %#v, want %#v", i, messages[0].Arguments[i], argument)
+				}
+			}
+		})
+	}
+}
+
+func TestMarshalLayout(t *testing.T) {
+	packet, err := NewMessage("/oscillator/4/frequency", float32(440)).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the example from the OSC 1.0 specification
+	want := []byte{
+		'/', 'o', 's', 'c', 'i', 'l', 'l', 'a', 't', 'o', 'r', '/', '4', '/', 'f', 'r',
+		'e', 'q', 'u', 'e', 'n', 'c', 'y', 0, ',', 'f', 0, 0, 0x43, 0xdc, 0, 0,
+	}
+
+	if !bytes.Equal(packet, want) {
+		t.Fatalf("packet = % x, want % x", packet, want)
+	}
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	if _, err := NewMessage("/fox/bad", struct{}{}).Marshal(); err == nil {
+		t.Fatal("expected an error for an unsupported argument")
+	}
+}
+
+func bundle(t *testing.T, elements ...[]byte) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+
+	writePaddedString(&buffer, bundleTag)
+	binary.Write(&buffer, binary.BigEndian, uint64(1))
+
+	for _, element := range elements {
+		binary.Write(&buffer, binary.BigEndian, int32(len(element)))
+		buffer.Write(element)
+	}
+
+	return buffer.Bytes()
+}
+
+func marshal(t *testing.T, address string, arguments ...any) []byte {
+	t.Helper()
+
+	packet, err := NewMessage(address, arguments...).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return packet
+}
+
+func TestUnmarshalBundle(t *testing.T) {
+	tests := []struct {
+		name    string
+		packet  []byte
+		address []string
+	}{
+		{"empty", bundle(t), []string{}},
+		{"single", bundle(t, marshal(t, "/fox/record")), []string{"/fox/record"}},
+		{"several", bundle(t, marshal(t, "/fox/marker", "intro"), marshal(t, "/fox/pause")), []string{"/fox/marker", "/fox/pause"}},
+		{"nested", bundle(t, marshal(t, "/a"), bundle(t, marshal(t, "/b"), marshal(t, "/c"))), []string{"/a", "/b", "/c"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			messages, err := Unmarshal(test.packet)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			addresses := make([]string, 0)
+			for _, message := range messages {
+				addresses = append(addresses, message.Address)
+			}
+
+			if !reflect.DeepEqual(addresses, test.address) {
+				t.Fatalf("addresses = %v, want %v", addresses, test.address)
+			}
+		})
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	oversized := bundle(t, marshal(t, "/a"))
+	binary.BigEndian.PutUint32(oversized[16:], 64)
+
+	tests := []struct {
+		name   string
+		packet []byte
+	}{
+		{"empty", []byte{}},
+		{"unaligned", []byte{'/', 'a', 0}},
+		{"no leading slash", []byte{'a', 'b', 'c', 0}},
+		{"unterminated address", []byte{'/', 'a', 'b', 'c'}},
+		{"bad type tags", append([]byte{'/', 'a', 0, 0}, 'f', 0, 0, 0)},
+		{"unknown type tag", append([]byte{'/', 'a', 0, 0}, ',', 'x', 0, 0)},
+		{"truncated int", append([]byte{'/', 'a', 0, 0}, ',', 'i', 0, 0)},
+		{"oversized blob", append([]byte{'/', 'a', 0, 0}, ',', 'b', 0, 0, 0, 0, 0, 64)},
+		{"oversized bundle element", oversized},
+		{"bad bundle tag", append([]byte{'#', 'b', 'u', 'n', 'd', 'l', 'x', 0}, make([]byte, 8)...)},
+		{"truncated time tag", []byte{'#', 'b', 'u', 'n', 'd', 'l', 'e', 0, 0, 0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if messages, err := Unmarshal(test.packet); err == nil {
+				t.Fatalf("expected an error, got %v", messages)
+			}
+		})
+	}
+}
+
+func TestMessageAccessors(t *testing.T) {
+	message := NewMessage("/fox/level", "Kick", int32(3), float32(0.5), int64(7), 2.25, true, " -6 ", nil)
+
+	strings := []struct {
+		index int
+		want  string
+		ok    bool
+	}{
+		{0, "Kick", true},
+		{1, "3", true},
+		{2, "0.5", true},
+		{3, "7", true},
+		{4, "2.25", true},
+		{5, "", false},
+		{7, "", false},
+		{8, "", false},
+	}
+
+	for _, test := range strings {
+		if got, ok := message.String(test.index); got != test.want || ok != test.ok {
+			t.Errorf("String(%d) = %q, %v, want %q, %v", test.index, got, ok, test.want, test.ok)
+		}
+	}
+
+	floats := []struct {
+		index int
+		want  float64
+		ok    bool
+	}{
+		{0, 0, false},
+		{1, 3, true},
+		{2, 0.5, true},
+		{3, 7, true},
+		{4, 2.25, true},
+		{5, 1, true},
+		{6, -6, true},
+		{7, 0, false},
+		{8, 0, false},
+	}
+
+	for _, test := range floats {
+		if got, ok := message.Float(test.index); got != test.want || ok != test.ok {
+			t.Errorf("Float(%d) = %v, %v, want %v, %v", test.index, got, ok, test.want, test.ok)
+		}
+	}
+}
@@ -29,14 +29,15 @@ import (
 	"fox-audio/reaper"
 )
 
-func CatchSigint(callback func()) {
+// CatchSignals calls the callback for each of the given signals received
+func CatchSignals(callback func(os.Signal), signals ...os.Signal) {
 	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
+	signal.Notify(c, signals...)
 	go func() {
 		defer reaper.HandlePanic()
 
-		for range c {
-			callback()
+		for sig := range c {
+			callback(sig)
 		}
 	}()
 }
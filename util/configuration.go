@@ -24,29 +24,103 @@ package util
 
 import (
 	"errors"
-	"fmt"
 	"log/slog"
-	"os"
 	"slices"
+	"strconv"
 	"strings"
-	"time"
 
 	"fox-audio/model"
-	"fox-audio/reaper"
 )
 
-func ReadProfile(profilePath string) (*model.Profile, error) {
+func ReadProfile(args *model.CommandLineArgs) (*model.Profile, error) {
+	profilePath := args.ProfileName
+
 	if !strings.HasSuffix(profilePath, ".profile") {
 		profilePath += ".profile"
 	}
 
-	profile := &model.Profile{}
+	profile := &model.Profile{
+		Output: model.ProfileOutput{
+			FilenameTemplate:    "{take}_channel{ports}_{channel}.wav",
+			TakeScheme:          model.TakeSchemeLetters,
+			TakeDigits:          2,
+			TakeTimestampFormat: "20060102-150405",
+			ErrorPolicy:         model.ErrorPolicyStopAll,
+			WriteRetries:        5,
+			WriteRetryDelayMs:   50,
+			Checksum:            true,
+		},
+		Console: model.ProfileConsole{
+			Type:      model.ConsoleTypeX32,
+			TimeoutMs: 1000,
+		},
+		Midi: model.ProfileMidi{
+			MmcDeviceId: model.MmcAllCall,
+			// the transport stops without ending the session
+			Mmc: map[string]string{
+				"stop":          model.MidiActionPause,
+				"play":          model.MidiActionRecord,
+				"deferred_play": model.MidiActionRecord,
+				"record_strobe": model.MidiActionRecord,
+				"record_exit":   model.MidiActionPause,
+				"record_pause":  model.MidiActionPause,
+				"pause":         model.MidiActionPause,
+				// consoles send locate on every return to zero, so it is only
+				// acted on when the profile asks for it
+				"locate": "",
+			},
+		},
+	}
 
 	if err := ReadYamlFile(profile, profilePath); err != nil {
 		return nil, err
 	}
 
-	prepareOutputDirectory(profile)
+	if !slices.Contains(model.ErrorPolicies, profile.Output.ErrorPolicy) {
+		err := errors.New("invalid error policy specified: " + profile.Output.ErrorPolicy + ". Valid options: " + strings.Join(model.ErrorPolicies, ", "))
+		return nil, err
+	}
+
+	if !slices.Contains(model.TransportModes, profile.AudioServer.Transport) {
+		err := errors.New("invalid transport specified: " + profile.AudioServer.Transport + ". Valid options: follow, master or empty to ignore the JACK transport")
+		return nil, err
+	}
+
+	if !slices.Contains(model.TakeSchemes, profile.Output.TakeScheme) {
+		err := errors.New("invalid take scheme specified: " + profile.Output.TakeScheme + ". Valid options: " + strings.Join(model.TakeSchemes, ", "))
+		return nil, err
+	}
+
+	for i := range profile.Loudness {
+		if _, err := LoudnessPorts(profile, &profile.Loudness[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if profile.Console.Address != "" {
+		if !slices.Contains(model.ConsoleTypes, profile.Console.Type) {
+			err := errors.New("invalid console type specified: " + profile.Console.Type + ". Valid options: " + strings.Join(model.ConsoleTypes, ", "))
+			return nil, err
+		}
+	}
+
+	if err := validateMidi(&profile.Midi); err != nil {
+		return nil, err
+	}
+
+	applyTokenArgs(profile, args)
+
+	if err := validateTokenNames(profile); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateFileNameTemplate(profile); err != nil {
+		return nil, err
+	}
+
+	if err := prepareOutputDirectory(profile); err != nil {
+		return nil, err
+	}
 
 	return profile, nil
 }
@@ -60,7 +134,8 @@ func ReadConfig(args *model.CommandLineArgs) (*model.Config, error) {
 		i++
 	}
 
-	if !slices.Contains(outputTypes, strings.ToLower(args.OutputType)) {
+	// commands without a display leave the output type empty
+	if args.OutputType != "" && !slices.Contains(outputTypes, strings.ToLower(args.OutputType)) {
 		err := errors.New("invalid output type specified: " + args.OutputType + ". Valid options: " + strings.Join(outputTypes, ", "))
 		return nil, err
 	}
@@ -78,21 +153,73 @@ func ReadConfig(args *model.CommandLineArgs) (*model.Config, error) {
 			FreezeMeters:     false,
 			ChannelCount:     32,
 		},
+		MeteringOptions: &model.MeteringOptions{
+			Standard:         model.MeterStandardDigital,
+			FallRateDb:       20.0,
+			ReferenceLevelDb: -18.0,
+			ClipSamples:      3,
+			OverThresholdDb:  0.0,
+		},
+		Theme: &model.ThemeOptions{
+			Name: "default",
+		},
+		Api: &model.ApiOptions{
+			Listen:         "",
+			PushIntervalMs: 250,
+		},
+		Osc: &model.OscOptions{
+			Listen:             "",
+			Feedback:           make([]string, 0),
+			FeedbackIntervalMs: 200,
+		},
 	}
 
 	if err := ReadYamlFile(config, args.ConfigFile); err != nil {
 		return nil, err
 	}
 
+	if !slices.Contains(model.MeterStandards, config.MeteringOptions.Standard) {
+		err := errors.New("invalid meter standard specified: " + config.MeteringOptions.Standard + ". Valid options: " + strings.Join(model.MeterStandards, ", "))
+		return nil, err
+	}
+
+	if config.MeteringOptions.FallRateDb <= 0 {
+		err := errors.New("invalid metering fall_rate_db: " + strconv.FormatFloat(config.MeteringOptions.FallRateDb, 'f', -1, 64) + ". Must be greater than 0")
+		return nil, err
+	}
+
+	if config.MeteringOptions.ClipSamples < 1 {
+		err := errors.New("invalid metering clip_samples: " + strconv.Itoa(config.MeteringOptions.ClipSamples) + ". Must be at least 1")
+		return nil, err
+	}
+
+	if config.Api.PushIntervalMs < 10 {
+		err := errors.New("invalid api push_interval_ms: " + strconv.Itoa(config.Api.PushIntervalMs) + ". Must be at least 10")
+		return nil, err
+	}
+
+	if config.Osc.FeedbackIntervalMs < 10 {
+		err := errors.New("invalid osc feedback_interval_ms: " + strconv.Itoa(config.Osc.FeedbackIntervalMs) + ". Must be at least 10")
+		return nil, err
+	}
+
 	if config.JackdBinary == "" {
 		config.JackdBinary = FindJackdBinary()
 	}
 
-	requestedOutputType := model.OutputTypeMap[args.OutputType]
-	if requestedOutputType != config.OutputType {
+	requestedOutputType, found := model.OutputTypeMap[args.OutputType]
+	if found && requestedOutputType != config.OutputType {
 		config.OutputType = requestedOutputType
 	}
 
+	if args.ApiListen != "" {
+		config.Api.Listen = args.ApiListen
+	}
+
+	if args.OscListen != "" {
+		config.Osc.Listen = args.OscListen
+	}
+
 	if args.Simulate != config.SimulationOptions.EnableSimulation {
 		config.SimulationOptions.EnableSimulation = args.Simulate
 	}
@@ -105,50 +232,64 @@ func ReadConfig(args *model.CommandLineArgs) (*model.Config, error) {
 		config.SimulationOptions.FreezeMeters = args.SimulateFreezeMeters
 	}
 
+	// any page a browser on the network opens could otherwise reach the API
+	if config.Api.Listen != "" && config.Api.Token == "" && !IsLoopbackHost(config.Api.Listen) {
+		err := errors.New("an api token is required to listen on " + config.Api.Listen + ", set one or listen on 127.0.0.1")
+		return nil, err
+	}
+
 	return config, nil
 }
 
-func prepareOutputDirectory(profile *model.Profile) {
-	var err error
-	outputDir, err := ResolveHomeDirPath(time.Now().Format(profile.Output.DirectoryTemplate))
-	if err != nil {
-		slog.Error("Failed to resolve home user dir: " + err.Error())
-		reaper.Reap()
-		return
+func validateMidi(midi *model.ProfileMidi) error {
+	if midi.MmcDeviceId < 0 || midi.MmcDeviceId > model.MmcAllCall {
+		return errors.New("invalid midi mmc_device_id: " + strconv.Itoa(midi.MmcDeviceId) + ". Must be between 0 and 127")
 	}
 
-	if !DirectoryExists(outputDir) {
-		slog.Info("Creating output directory: " + outputDir)
-		os.MkdirAll(outputDir, 0755)
-	}
+	for command, action := range midi.Mmc {
+		if _, found := model.MmcCommands[command]; !found {
+			return errors.New("invalid midi mmc command: " + command)
+		}
 
-	// set the calculated values in the profile for other parts of the app to use
-	profile.Output.Take = getTake(outputDir)
-	profile.Output.Directory = outputDir
-}
+		// an empty action ignores the command
+		if action != "" && !slices.Contains(model.MidiActions, action) {
+			return errors.New("invalid midi action for mmc " + command + ": " + action + ". Valid options: " + strings.Join(model.MidiActions, ", "))
+		}
+	}
 
-func getTake(outputDir string) string {
-	entries, _ := os.ReadDir(outputDir)
+	for _, mapping := range midi.Mappings {
+		if !slices.Contains(model.MidiTypes, mapping.Type) {
+			return errors.New("invalid midi mapping type: " + mapping.Type + ". Valid options: " + strings.Join(model.MidiTypes, ", "))
+		}
 
-	take := byte('A')
+		if mapping.Channel < 0 || mapping.Channel > 16 {
+			return errors.New("invalid midi mapping channel: " + strconv.Itoa(mapping.Channel) + ". Must be between 1 and 16, or 0 for any")
+		}
 
-out:
-	for {
-		for _, entry := range entries {
-			name := entry.Name()
+		if mapping.Number < 0 || mapping.Number > 127 {
+			return errors.New("invalid midi mapping number: " + strconv.Itoa(mapping.Number) + ". Must be between 0 and 127")
+		}
 
-			// skip directories or non-wav files
-			if entry.IsDir() || !strings.HasSuffix(name, ".wav") {
-				continue
-			}
+		if !slices.Contains(model.MidiActions, mapping.Action) {
+			return errors.New("invalid midi action: " + mapping.Action + ". Valid options: " + strings.Join(model.MidiActions, ", "))
+		}
 
-			if strings.HasPrefix(name, fmt.Sprintf("%s_channel", string(take))) {
-				take += 1
-				continue out
-			}
+		if (mapping.Action == model.MidiActionArm || mapping.Action == model.MidiActionDisarm) && mapping.Argument == "" {
+			return errors.New("midi " + mapping.Action + " mapping needs the input number or channel name as its argument")
 		}
-		break out
 	}
 
-	return string(take)
+	return nil
+}
+
+func prepareOutputDirectory(profile *model.Profile) error {
+	output, err := NextTake(profile, profile.Output.TakeTime)
+	if err != nil {
+		return err
+	}
+
+	// set the calculated values in the profile for other parts of the app to use
+	profile.Output = output
+
+	return nil
 }
@@ -0,0 +1,266 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package util
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fox-audio/model"
+	"fox-audio/osc"
+)
+
+const (
+	x32Port     = "10023"
+	x32Channels = 32
+)
+
+var (
+	x32ReplyPattern = regexp.MustCompile(`^/ch/(\d\d)/(config/name|mix/on)$`)
+)
+
+// consoleChannel is what the console reported for one of its channels
+type consoleChannel struct {
+	name    string
+	named   bool
+	muted   bool
+	hasMute bool
+}
+
+// ImportConsoleNames replaces the profile channel names with the scribble
+// strip names set on the console. Channels the console doesn't answer for keep
+// their profile names. The profile is left alone if the console can't be
+// reached or its names would give two channels the same file name.
+func ImportConsoleNames(profile *model.Profile) error {
+	console := &profile.Console
+
+	numbers := make([]int, 0)
+	for _, channel := range profile.Channels {
+		if number := consoleChannelNumber(&channel); number > 0 && !slices.Contains(numbers, number) {
+			numbers = append(numbers, number)
+		}
+	}
+
+	if len(numbers) == 0 {
+		return nil
+	}
+
+	states, err := queryX32(console, numbers)
+	if err != nil {
+		return err
+	}
+
+	// renamed on a copy so nothing changes if the names clash
+	imported := *profile
+	imported.Channels = slices.Clone(profile.Channels)
+	imported.Loudness = slices.Clone(profile.Loudness)
+
+	named := 0
+
+	for i := range imported.Channels {
+		channel := &imported.Channels[i]
+
+		if state, found := states[consoleChannelNumber(channel)]; found && state.named && state.name != "" {
+			renameChannel(&imported, channel, state.name)
+			named++
+		}
+	}
+
+	if err := validateChannelNames(imported.Channels); err != nil {
+		return errors.New("the console names can't be used, " + err.Error())
+	}
+
+	profile.Channels = imported.Channels
+	profile.Loudness = imported.Loudness
+
+	for i := range profile.Channels {
+		channel := &profile.Channels[i]
+
+		state, found := states[consoleChannelNumber(channel)]
+		if !found {
+			continue
+		}
+
+		if state.named && state.name == "" && console.DisableUnnamed && !channel.Disabled {
+			slog.Info("Disabling channel '" + channel.ChannelName + "', it is not named on the console")
+			channel.Disabled = true
+		}
+
+		if state.hasMute && state.muted && console.DisableMuted && !channel.Disabled {
+			slog.Info("Disabling channel '" + channel.ChannelName + "', it is muted on the console")
+			channel.Disabled = true
+		}
+	}
+
+	if len(states) < len(numbers) {
+		slog.Warn(fmt.Sprintf("The console answered for %d of %d channels, the others keep their profile names", len(states), len(numbers)))
+	}
+
+	slog.Info(fmt.Sprintf("Imported %d channel names from the console at %s", named, console.Address))
+
+	return nil
+}
+
+//
+// private functions
+//
+
+// consoleChannelNumber returns the 1 based console channel a profile channel
+// is named after, or 0 if there is none
+func consoleChannelNumber(channel *model.ProfileChannel) int {
+	number := channel.ConsoleChannel
+	if number == 0 && len(channel.Ports) > 0 {
+		number = channel.Ports[0]
+	}
+
+	if number < 1 || number > x32Channels {
+		return 0
+	}
+
+	return number
+}
+
+// validateChannelNames makes sure no two channels end up writing to the same
+// file, names are compared the way a case insensitive file system would
+func validateChannelNames(channels []model.ProfileChannel) error {
+	for i := range channels {
+		for j := i + 1; j < len(channels); j++ {
+			if strings.EqualFold(sanitizeFileName(channels[i].ChannelName), sanitizeFileName(channels[j].ChannelName)) {
+				return errors.New("channel name '" + channels[j].ChannelName + "' is used more than once")
+			}
+		}
+	}
+
+	return nil
+}
+
+// renameChannel also updates the loudness meters that refer to the channel by
+// name
+func renameChannel(profile *model.Profile, channel *model.ProfileChannel, name string) {
+	for i := range profile.Loudness {
+		if profile.Loudness[i].Channel == channel.ChannelName {
+			profile.Loudness[i].Channel = name
+		}
+	}
+
+	slog.Debug("Channel '" + channel.ChannelName + "' is named '" + name + "' on the console")
+	channel.ChannelName = name
+}
+
+// queryX32 asks the console for the name, and the mute state if needed, of
+// each channel. Every query is sent up front and the answers are collected
+// until they are all in or the timeout passes.
+func queryX32(console *model.ProfileConsole, numbers []int) (map[int]*consoleChannel, error) {
+	address := console.Address
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, x32Port)
+	}
+
+	remote, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, errors.New("invalid console address " + console.Address + ": " + err.Error())
+	}
+
+	// connected, so only the console's answers are read
+	conn, err := net.DialUDP("udp", nil, remote)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	pending := 0
+
+	for _, number := range numbers {
+		queries := []string{fmt.Sprintf("/ch/%02d/config/name", number)}
+		if console.DisableMuted {
+			queries = append(queries, fmt.Sprintf("/ch/%02d/mix/on", number))
+		}
+
+		for _, query := range queries {
+			packet, _ := osc.NewMessage(query).Marshal()
+			if _, err := conn.Write(packet); err != nil {
+				return nil, err
+			}
+			pending++
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Duration(console.TimeoutMs) * time.Millisecond))
+
+	states := make(map[int]*consoleChannel)
+	buffer := make([]byte, 1500)
+
+	for pending > 0 {
+		size, err := conn.Read(buffer)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		messages, err := osc.Unmarshal(buffer[:size])
+		if err != nil {
+			continue
+		}
+
+		for _, message := range messages {
+			match := x32ReplyPattern.FindStringSubmatch(message.Address)
+			if match == nil {
+				continue
+			}
+
+			number, _ := strconv.Atoi(match[1])
+
+			state, found := states[number]
+			if !found {
+				state = &consoleChannel{}
+				states[number] = state
+			}
+
+			if match[2] == "config/name" {
+				name, _ := message.String(0)
+				state.name = strings.TrimSpace(name)
+				state.named = true
+			} else if on, ok := message.Float(0); ok {
+				state.muted = on == 0
+				state.hasMute = true
+			}
+
+			pending--
+		}
+	}
+
+	if len(states) == 0 {
+		return nil, errors.New("no answer from " + address + " within " + strconv.Itoa(console.TimeoutMs) + " ms")
+	}
+
+	return states, nil
+}
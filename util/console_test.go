@@ -0,0 +1,193 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package util
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"fox-audio/model"
+	"fox-audio/osc"
+)
+
+// fakeX32 answers name and mute queries like a console would, channels not in
+// names are ignored
+func fakeX32(t *testing.T, names map[int]string, muted map[int]bool) string {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buffer := make([]byte, 1500)
+
+		for {
+			size, sender, err := conn.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+
+			messages, err := osc.Unmarshal(buffer[:size])
+			if err != nil {
+				continue
+			}
+
+			for _, message := range messages {
+				match := x32ReplyPattern.FindStringSubmatch(message.Address)
+				if match == nil {
+					continue
+				}
+
+				number, _ := strconv.Atoi(match[1])
+
+				name, found := names[number]
+				if !found {
+					continue
+				}
+
+				var reply *osc.Message
+				if match[2] == "config/name" {
+					reply = osc.NewMessage(message.Address, name)
+				} else {
+					on := int32(1)
+					if muted[number] {
+						on = 0
+					}
+					reply = osc.NewMessage(message.Address, on)
+				}
+
+				packet, _ := reply.Marshal()
+				conn.WriteToUDP(packet, sender)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestQueryX32(t *testing.T) {
+	address := fakeX32(t, map[int]string{1: "Kick ", 2: "", 3: "Vox"}, map[int]bool{3: true})
+
+	console := &model.ProfileConsole{Address: address, TimeoutMs: 500, DisableMuted: true}
+
+	states, err := queryX32(console, []int{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		number int
+		found  bool
+		name   string
+		named  bool
+		muted  bool
+	}{
+		{1, true, "Kick", true, false},
+		{2, true, "", true, false},
+		{3, true, "Vox", true, true},
+		{4, false, "", false, false},
+	}
+
+	for _, test := range tests {
+		state, found := states[test.number]
+
+		if found != test.found {
+			t.Errorf("channel %d found %v, expected %v", test.number, found, test.found)
+			continue
+		}
+
+		if !found {
+			continue
+		}
+
+		if state.name != test.name || state.named != test.named || state.muted != test.muted || !state.hasMute {
+			t.Errorf("channel %d got %+v, expected name %q named %v muted %v", test.number, *state, test.name, test.named, test.muted)
+		}
+	}
+}
+
+func TestQueryX32Timeout(t *testing.T) {
+	address := fakeX32(t, map[int]string{}, nil)
+
+	console := &model.ProfileConsole{Address: address, TimeoutMs: 100}
+
+	start := time.Now()
+	_, err := queryX32(console, []int{1, 2})
+
+	if err == nil || !strings.Contains(err.Error(), "no answer") {
+		t.Errorf("expected no answer error, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("query took %s, expected it to give up after the timeout", elapsed)
+	}
+}
+
+func TestImportConsoleNames(t *testing.T) {
+	newProfile := func(address string) *model.Profile {
+		return &model.Profile{
+			Console: model.ProfileConsole{Address: address, TimeoutMs: 200, DisableUnnamed: true},
+			Channels: []model.ProfileChannel{
+				{ChannelName: "in1", Ports: []int{1}},
+				{ChannelName: "in2", Ports: []int{2}},
+				{ChannelName: "in3", Ports: []int{3}},
+			},
+			Loudness: []model.ProfileLoudness{{Name: "main", Channel: "in1"}},
+		}
+	}
+
+	profile := newProfile(fakeX32(t, map[int]string{1: "Kick", 2: ""}, nil))
+
+	if err := ImportConsoleNames(profile); err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Channels[0].ChannelName != "Kick" || profile.Loudness[0].Channel != "Kick" {
+		t.Errorf("channel 1 not renamed: %+v %+v", profile.Channels[0], profile.Loudness[0])
+	}
+
+	if !profile.Channels[1].Disabled {
+		t.Error("unnamed channel 2 not disabled")
+	}
+
+	if profile.Channels[2].ChannelName != "in3" || profile.Channels[2].Disabled {
+		t.Errorf("unanswered channel 3 changed: %+v", profile.Channels[2])
+	}
+
+	// clashing names leave the profile alone
+	profile = newProfile(fakeX32(t, map[int]string{1: "Vox", 3: "vox"}, nil))
+
+	if err := ImportConsoleNames(profile); err == nil {
+		t.Error("expected clashing names to fail")
+	}
+
+	if profile.Channels[0].ChannelName != "in1" || profile.Channels[2].ChannelName != "in3" || profile.Loudness[0].Channel != "in1" {
+		t.Errorf("profile changed by a failed import: %+v", profile.Channels)
+	}
+}
@@ -0,0 +1,76 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"fox-audio/model"
+)
+
+// LoudnessPorts returns the input ports measured by a loudness meter, either
+// taken from the referenced profile channel or listed directly
+func LoudnessPorts(profile *model.Profile, loudness *model.ProfileLoudness) ([]int, error) {
+	ports := loudness.Ports
+
+	if loudness.Channel != "" {
+		if len(loudness.Ports) > 0 {
+			return nil, errors.New("loudness meter '" + loudness.Name + "' must specify either a channel or ports, not both")
+		}
+
+		found := false
+		for _, channel := range profile.Channels {
+			if channel.ChannelName == loudness.Channel {
+				ports = channel.Ports
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, errors.New("loudness meter '" + loudness.Name + "' references unknown channel '" + loudness.Channel + "'")
+		}
+	}
+
+	if loudness.Name == "" {
+		loudness.Name = loudness.Channel
+	}
+
+	if loudness.Name == "" {
+		loudness.Name = fmt.Sprint(ports)
+	}
+
+	if len(ports) < 1 || len(ports) > 2 {
+		return nil, errors.New("loudness meter '" + loudness.Name + "' must measure a mono or stereo source (1 or 2 ports)")
+	}
+
+	for _, port := range ports {
+		if port < 1 {
+			return nil, errors.New("loudness meter '" + loudness.Name + "' has invalid port " + strconv.Itoa(port))
+		}
+	}
+
+	return ports, nil
+}
@@ -24,9 +24,11 @@ package util
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
@@ -156,6 +158,42 @@ func ReadYamlFile(cfg interface{}, fileName string) error {
 	return nil
 }
 
+// WriteJsonFile writes the value to a temporary file and renames it into place
+// so readers never see a partially written file
+func WriteJsonFile(filePath string, v any) error {
+	jsonBytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := filePath + ".tmp"
+
+	if err := os.WriteFile(tmpPath, jsonBytes, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, filePath)
+}
+
+// IsLoopbackHost reports whether a host, or a host:port address, only reaches
+// this machine. An empty host listens on every interface, so it doesn't.
+func IsLoopbackHost(address string) bool {
+	host := address
+	if splitHost, _, err := net.SplitHostPort(address); err == nil {
+		host = splitHost
+	}
+
+	host = strings.Trim(host, "[]")
+
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
 func TraceLog(message string, args ...any) {
 	slog.Log(context.Background(), slog.Level(-8), message, args...)
 }
@@ -1,4 +1,4 @@
-//go:build darwin
+//go:build darwin || linux
 
 // =================================================================================
 //
@@ -30,7 +30,6 @@ import (
 	"fox-audio/model"
 )
 
-// TODO: make this linux happy
 func GetDiskSpace(path string) model.DiskInfo {
 	stat := syscall.Statfs_t{}
 
@@ -47,3 +47,13 @@ out:
 func AmplitudeToDb(amplitude float32) float64 {
 	return math.Log10(float64(amplitude)) * 20.0
 }
+
+// AmplitudeToDbFloor converts to dB, clamping silence to the given floor so
+// the result is always a finite number
+func AmplitudeToDbFloor(amplitude float32, floor float64) float64 {
+	if amplitude <= 0 {
+		return floor
+	}
+
+	return math.Max(AmplitudeToDb(amplitude), floor)
+}
@@ -0,0 +1,263 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package util
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"fox-audio/model"
+)
+
+// FileName builds the output file name for a profile channel from the
+// profile's filename template
+func FileName(profile *model.Profile, channel *model.ProfileChannel) (string, error) {
+	return ExpandTemplate(profile.Output.FilenameTemplate, fileNameTokens(profile, channel))
+}
+
+// FormatTake returns the take name for the given 1-based take number
+func FormatTake(output *model.ProfileOutput, number int, takeTime time.Time) string {
+	switch output.TakeScheme {
+	case model.TakeSchemeNumbers:
+		return fmt.Sprintf("%0*d", output.TakeDigits, number)
+	case model.TakeSchemeTimestamp:
+		return takeTime.Format(output.TakeTimestampFormat)
+	}
+
+	return takeLetters(number)
+}
+
+// NextTake works out the take that starts at takeTime. The output directory
+// is resolved again so {date} and {time} follow the take. The profile is left
+// alone, the returned output settings replace its own once the take is used.
+func NextTake(profile *model.Profile, takeTime time.Time) (model.ProfileOutput, error) {
+	next := *profile
+	next.Output.TakeTime = takeTime
+
+	outputDir, err := ResolveDirectory(&next)
+	if err != nil {
+		return next.Output, errors.New("failed to resolve output directory: " + err.Error())
+	}
+
+	if !DirectoryExists(outputDir) {
+		slog.Info("Creating output directory: " + outputDir)
+		os.MkdirAll(outputDir, 0755)
+	}
+
+	take, takeNumber, err := getTake(&next, outputDir)
+	if err != nil {
+		return next.Output, errors.New("failed to determine take: " + err.Error())
+	}
+
+	next.Output.Take = take
+	next.Output.TakeNumber = takeNumber
+	next.Output.Directory = outputDir
+
+	return next.Output, nil
+}
+
+// ValidateFileNameTemplate makes sure the template only uses known tokens and
+// is able to tell one take from another
+func ValidateFileNameTemplate(profile *model.Profile) error {
+	template := profile.Output.FilenameTemplate
+
+	if !TemplateHasToken(template, "take") && !TemplateHasToken(template, "take_number") {
+		return errors.New("filename template must include {take} or {take_number}: " + template)
+	}
+
+	if _, err := TemplatePattern(template, fileNamePatterns(profile)); err != nil {
+		return errors.New(err.Error() + userTokenHint)
+	}
+
+	return nil
+}
+
+//
+// private functions
+//
+
+func fileNameTokens(profile *model.Profile, channel *model.ProfileChannel) map[string]TemplateToken {
+	tokens := commonTokens(profile)
+
+	tokens["take"] = func(arg string) string {
+		return profile.Output.Take
+	}
+
+	tokens["take_number"] = func(arg string) string {
+		digits := profile.Output.TakeDigits
+		if width, err := strconv.Atoi(arg); err == nil {
+			digits = width
+		}
+
+		return fmt.Sprintf("%0*d", digits, profile.Output.TakeNumber)
+	}
+
+	tokens["channel"] = func(arg string) string {
+		return sanitizeFileName(channel.ChannelName)
+	}
+
+	tokens["ports"] = func(arg string) string {
+		separator := "-"
+		if arg != "" {
+			separator = arg
+		}
+
+		portNumbers := make([]string, len(channel.Ports))
+		for i, port := range channel.Ports {
+			portNumbers[i] = fmt.Sprintf("%02d", port)
+		}
+
+		return strings.Join(portNumbers, separator)
+	}
+
+	tokens["sample_rate"] = func(arg string) string {
+		return strconv.Itoa(profile.AudioServer.SampleRate)
+	}
+
+	return tokens
+}
+
+func fileNamePatterns(profile *model.Profile) map[string]string {
+	takePattern := "[A-Z]+"
+
+	if profile.Output.TakeScheme == model.TakeSchemeNumbers {
+		takePattern = "[0-9]+"
+	} else if profile.Output.TakeScheme == model.TakeSchemeTimestamp {
+		takePattern = ".+?"
+	}
+
+	patterns := commonPatterns(profile)
+
+	patterns["take"] = takePattern
+	patterns["take_number"] = "[0-9]+"
+	patterns["channel"] = ".*?"
+	patterns["ports"] = ".*?"
+	patterns["sample_rate"] = "[0-9]+"
+
+	return patterns
+}
+
+// getTake scans the output directory for files matching the filename template
+// and returns the name and number of the next take
+func getTake(profile *model.Profile, outputDir string) (string, int, error) {
+	pattern, err := TemplatePattern(profile.Output.FilenameTemplate, fileNamePatterns(profile))
+	if err != nil {
+		return "", 0, err
+	}
+
+	takeIndex := pattern.SubexpIndex("take")
+	takeNumberIndex := pattern.SubexpIndex("take_number")
+
+	entries, _ := os.ReadDir(outputDir)
+
+	lastNumber := 0
+	takes := make(map[string]bool)
+
+	for _, entry := range entries {
+		// skip directories
+		if entry.IsDir() {
+			continue
+		}
+
+		match := pattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		if takeIndex >= 0 {
+			take := match[takeIndex]
+			takes[take] = true
+			lastNumber = max(lastNumber, parseTake(profile.Output.TakeScheme, take))
+		}
+
+		if takeNumberIndex >= 0 {
+			number, _ := strconv.Atoi(match[takeNumberIndex])
+			lastNumber = max(lastNumber, number)
+		}
+	}
+
+	// timestamps don't carry a sequence, so fall back to counting the takes
+	lastNumber = max(lastNumber, len(takes))
+
+	number := lastNumber + 1
+
+	return FormatTake(&profile.Output, number, profile.Output.TakeTime), number, nil
+}
+
+func parseTake(takeScheme string, take string) int {
+	switch takeScheme {
+	case model.TakeSchemeLetters:
+		return takeLettersIndex(take)
+	case model.TakeSchemeNumbers:
+		number, _ := strconv.Atoi(take)
+		return number
+	}
+
+	return 0
+}
+
+// takeLetters converts a 1-based take number to letters, rolling over from
+// Z to AA, AB and so on
+func takeLetters(number int) string {
+	letters := ""
+
+	for number > 0 {
+		number--
+		letters = string(rune('A'+number%26)) + letters
+		number /= 26
+	}
+
+	return letters
+}
+
+func takeLettersIndex(letters string) int {
+	number := 0
+
+	for _, letter := range letters {
+		if letter < 'A' || letter > 'Z' {
+			return 0
+		}
+
+		number = number*26 + int(letter-'A') + 1
+	}
+
+	return number
+}
+
+// sanitizeFileName keeps a value from adding path elements, so it can't
+// escape the directory it is used in
+func sanitizeFileName(value string) string {
+	value = strings.ReplaceAll(value, "/", "_")
+	value = strings.ReplaceAll(value, string(os.PathSeparator), "_")
+
+	if value == "." || value == ".." {
+		value = strings.Repeat("_", len(value))
+	}
+
+	return value
+}
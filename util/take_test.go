@@ -0,0 +1,230 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fox-audio/model"
+)
+
+func TestTakeLetters(t *testing.T) {
+	tests := []struct {
+		number  int
+		letters string
+	}{
+		{1, "A"},
+		{26, "Z"},
+		{27, "AA"},
+		{52, "AZ"},
+		{53, "BA"},
+		{702, "ZZ"},
+		{703, "AAA"},
+	}
+
+	for _, test := range tests {
+		if got := takeLetters(test.number); got != test.letters {
+			t.Errorf("takeLetters(%d) = %q, want %q", test.number, got, test.letters)
+		}
+	}
+
+	for number := 1; number <= 20000; number++ {
+		if got := takeLettersIndex(takeLetters(number)); got != number {
+			t.Fatalf("takeLettersIndex(takeLetters(%d)) = %d", number, got)
+		}
+	}
+
+	if got := takeLettersIndex("Ab"); got != 0 {
+		t.Errorf("takeLettersIndex(\"Ab\") = %d, want 0", got)
+	}
+}
+
+func TestFormatTake(t *testing.T) {
+	takeTime := time.Date(2024, 9, 27, 20, 50, 56, 0, time.UTC)
+
+	tests := []struct {
+		scheme string
+		digits int
+		number int
+		want   string
+	}{
+		{model.TakeSchemeLetters, 2, 3, "C"},
+		{model.TakeSchemeNumbers, 2, 3, "03"},
+		{model.TakeSchemeNumbers, 3, 1234, "1234"},
+		{model.TakeSchemeTimestamp, 2, 3, "20240927-205056"},
+	}
+
+	for _, test := range tests {
+		output := &model.ProfileOutput{
+			TakeScheme:          test.scheme,
+			TakeDigits:          test.digits,
+			TakeTimestampFormat: "20060102-150405",
+		}
+
+		if got := FormatTake(output, test.number, takeTime); got != test.want {
+			t.Errorf("FormatTake(%s, %d) = %q, want %q", test.scheme, test.number, got, test.want)
+		}
+	}
+}
+
+func takeProfile(scheme string, template string) *model.Profile {
+	return &model.Profile{
+		Name:   "live",
+		Tokens: map[string]string{"venue": "the_hall"},
+		AudioServer: model.ProfileAudioServer{
+			SampleRate: 48000,
+		},
+		Output: model.ProfileOutput{
+			FilenameTemplate:    template,
+			TakeScheme:          scheme,
+			TakeDigits:          2,
+			TakeTimestampFormat: "20060102-150405",
+		},
+	}
+}
+
+// TestGetTakeRoundTrip writes the files of a few takes with FileName and makes
+// sure getTake picks the take after them
+func TestGetTakeRoundTrip(t *testing.T) {
+	channels := []model.ProfileChannel{
+		{Ports: []int{1}, ChannelName: "Kick"},
+		{Ports: []int{2, 3}, ChannelName: "Over_heads"},
+		{Ports: []int{4}, ChannelName: "take_B"},
+	}
+
+	tests := []struct {
+		name     string
+		scheme   string
+		template string
+		takes    int
+		want     string
+	}{
+		{"letters", model.TakeSchemeLetters, "{take}_channel{ports}_{channel}.wav", 3, "D"},
+		{"letters past Z", model.TakeSchemeLetters, "{take}_channel{ports}_{channel}.wav", 27, "AB"},
+		{"numbers", model.TakeSchemeNumbers, "{take}_{channel}.wav", 9, "10"},
+		{"timestamp", model.TakeSchemeTimestamp, "{take}_{channel}.wav", 2, "20240927-205056"},
+		{"take number", model.TakeSchemeLetters, "{profile}_{take_number:3}_{channel}.wav", 4, "E"},
+		{"user token", model.TakeSchemeNumbers, "{venue}-{take}-{ports:+}-{sample_rate}.wav", 2, "03"},
+		{"no takes", model.TakeSchemeLetters, "{take}_{channel}.wav", 0, "A"},
+	}
+
+	firstTake := time.Date(2024, 9, 27, 20, 0, 0, 0, time.UTC)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			profile := takeProfile(test.scheme, test.template)
+
+			if err := ValidateFileNameTemplate(profile); err != nil {
+				t.Fatal(err)
+			}
+
+			for number := 1; number <= test.takes; number++ {
+				takeTime := firstTake.Add(time.Duration(number) * time.Minute)
+
+				profile.Output.TakeNumber = number
+				profile.Output.Take = FormatTake(&profile.Output, number, takeTime)
+
+				for i := range channels {
+					name, err := FileName(profile, &channels[i])
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			// files that don't match the template and directories are ignored
+			os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644)
+			os.Mkdir(filepath.Join(dir, "ZZ_channel01_Kick.wav"), 0755)
+
+			profile.Output.TakeTime = time.Date(2024, 9, 27, 20, 50, 56, 0, time.UTC)
+
+			take, number, err := getTake(profile, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if take != test.want || number != test.takes+1 {
+				t.Fatalf("getTake = %q, %d, want %q, %d", take, number, test.want, test.takes+1)
+			}
+		})
+	}
+}
+
+func TestNextTake(t *testing.T) {
+	root := t.TempDir()
+
+	profile := takeProfile(model.TakeSchemeLetters, "{take}_{channel}.wav")
+	profile.Output.DirectoryTemplate = root + "/{profile}/{date}_{time:1504}"
+	profile.Output.Take = "C"
+	profile.Output.TakeNumber = 3
+
+	takeTime := time.Date(2024, 9, 27, 20, 50, 56, 0, time.Local)
+
+	output, err := NextTake(profile, takeTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantDir := filepath.Join(root, "live", "2024-09-27_2050")
+
+	if output.Directory != wantDir || !DirectoryExists(wantDir) {
+		t.Fatalf("directory = %q, want %q to be created", output.Directory, wantDir)
+	}
+
+	if output.Take != "A" || output.TakeNumber != 1 || !output.TakeTime.Equal(takeTime) {
+		t.Fatalf("got take %q number %d at %v", output.Take, output.TakeNumber, output.TakeTime)
+	}
+
+	// the profile is only changed once the caller accepts the take
+	if profile.Output.Take != "C" || profile.Output.TakeNumber != 3 || !profile.Output.TakeTime.IsZero() {
+		t.Fatalf("profile output changed: %+v", profile.Output)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Kick", "Kick"},
+		{"L/R", "L_R"},
+		{"../etc", ".._etc"},
+		{".", "_"},
+		{"..", "__"},
+		{"...", "..."},
+	}
+
+	for _, test := range tests {
+		if got := sanitizeFileName(test.value); got != test.want {
+			t.Errorf("sanitizeFileName(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package util
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// TemplateToken resolves the value of a single {name} or {name:arg} token
+type TemplateToken func(arg string) string
+
+type templatePart struct {
+	literal string
+	isToken bool
+	name    string
+	arg     string
+}
+
+// ExpandTemplate replaces each {name} or {name:arg} token in the template with
+// the value returned by the matching token function. A literal brace can be
+// written as {{ or }}.
+func ExpandTemplate(template string, tokens map[string]TemplateToken) (string, error) {
+	parts, err := parseTemplate(template)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	for _, part := range parts {
+		if !part.isToken {
+			sb.WriteString(part.literal)
+			continue
+		}
+
+		token, found := tokens[part.name]
+		if !found {
+			return "", errors.New("unknown template token: {" + part.name + "}")
+		}
+
+		sb.WriteString(token(part.arg))
+	}
+
+	return sb.String(), nil
+}
+
+// TemplatePattern builds a regular expression that matches strings produced
+// by the template. Each token is matched by the pattern provided for it and
+// captured in a group named after the token.
+func TemplatePattern(template string, patterns map[string]string) (*regexp.Regexp, error) {
+	parts, err := parseTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for _, part := range parts {
+		if !part.isToken {
+			sb.WriteString(regexp.QuoteMeta(part.literal))
+			continue
+		}
+
+		pattern, found := patterns[part.name]
+		if !found {
+			return nil, errors.New("unknown template token: {" + part.name + "}")
+		}
+
+		sb.WriteString("(?P<" + part.name + ">" + pattern + ")")
+	}
+
+	sb.WriteString("$")
+
+	return regexp.Compile(sb.String())
+}
+
+// TemplateHasToken returns true if the template references the named token
+func TemplateHasToken(template string, name string) bool {
+	parts, err := parseTemplate(template)
+	if err != nil {
+		return false
+	}
+
+	for _, part := range parts {
+		if part.isToken && part.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseTemplate(template string) ([]templatePart, error) {
+	parts := make([]templatePart, 0)
+	literal := strings.Builder{}
+
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+
+		if c == '}' && i+1 < len(template) && template[i+1] == '}' {
+			literal.WriteByte('}')
+			i++
+			continue
+		}
+
+		if c != '{' {
+			literal.WriteByte(c)
+			continue
+		}
+
+		if i+1 < len(template) && template[i+1] == '{' {
+			literal.WriteByte('{')
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(template[i:], '}')
+		if end < 0 {
+			return nil, errors.New("unterminated token in template: " + template)
+		}
+
+		if literal.Len() > 0 {
+			parts = append(parts, templatePart{literal: literal.String()})
+			literal.Reset()
+		}
+
+		name, arg, _ := strings.Cut(template[i+1:i+end], ":")
+
+		if name == "" {
+			return nil, errors.New("empty token in template: " + template)
+		}
+
+		parts = append(parts, templatePart{
+			isToken: true,
+			name:    name,
+			arg:     arg,
+		})
+
+		i += end
+	}
+
+	if literal.Len() > 0 {
+		parts = append(parts, templatePart{literal: literal.String()})
+	}
+
+	return parts, nil
+}
@@ -0,0 +1,166 @@
+// =================================================================================
+//
+//			fox-audio - https://www.foxhollow.cc/projects/fox-audio/
+//
+//		 Fox Audio is a simple CLI utility for recording and playback of
+//	  multitrack audio straight to disk by utilizing the JACK audio server
+//
+//		 Copyright (c) 2024 Steve Cross <flip@foxhollow.cc>
+//
+//			Licensed under the Apache License, Version 2.0 (the "License");
+//			you may not use this file except in compliance with the License.
+//			You may obtain a copy of the License at
+//
+//			     http://www.apache.org/licenses/LICENSE-2.0
+//
+//			Unless required by applicable law or agreed to in writing, software
+//			distributed under the License is distributed on an "AS IS" BASIS,
+//			WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//			See the License for the specific language governing permissions and
+//			limitations under the License.
+//
+// =================================================================================
+package util
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"fox-audio/model"
+)
+
+const (
+	userTokenHint = ". User defined tokens can be set with --event, --venue, --token or in the profile tokens section"
+)
+
+var (
+	// token names become named groups when matching existing takes
+	tokenNamePattern = regexp.MustCompile("^[A-Za-z_][A-Za-z0-9_]*$")
+)
+
+// ResolveDirectory expands the profile's directory template. Templates that
+// don't contain any {tokens} are treated as a plain Go time layout, which is
+// how directory templates used to work.
+func ResolveDirectory(profile *model.Profile) (string, error) {
+	template := profile.Output.DirectoryTemplate
+
+	if !strings.Contains(template, "{") {
+		legacyDir := profile.Output.TakeTime.Format(template)
+
+		if legacyDir != template {
+			slog.Warn("directory_template has no {tokens} and is being treated as a time layout, consider using {date:...} instead")
+		}
+
+		return ResolveHomeDirPath(legacyDir)
+	}
+
+	outputDir, err := ExpandTemplate(template, commonTokens(profile))
+	if err != nil {
+		return "", errors.New(err.Error() + userTokenHint)
+	}
+
+	return ResolveHomeDirPath(outputDir)
+}
+
+// commonTokens returns the tokens that are available to both directory and
+// filename templates
+func commonTokens(profile *model.Profile) map[string]TemplateToken {
+	tokens := make(map[string]TemplateToken)
+
+	// user defined tokens go first so that built in tokens always win
+	for name, value := range profile.Tokens {
+		tokens[name] = func(arg string) string {
+			return sanitizeFileName(value)
+		}
+	}
+
+	tokens["profile"] = func(arg string) string {
+		return sanitizeFileName(profile.Name)
+	}
+
+	tokens["date"] = func(arg string) string {
+		if arg == "" {
+			arg = "2006-01-02"
+		}
+
+		return profile.Output.TakeTime.Format(arg)
+	}
+
+	tokens["time"] = func(arg string) string {
+		if arg == "" {
+			arg = "150405"
+		}
+
+		return profile.Output.TakeTime.Format(arg)
+	}
+
+	tokens["hostname"] = func(arg string) string {
+		hostname, err := os.Hostname()
+		if err != nil {
+			slog.Warn("Failed to read hostname: " + err.Error())
+			return "unknown"
+		}
+
+		return sanitizeFileName(hostname)
+	}
+
+	tokens["env"] = func(arg string) string {
+		return sanitizeFileName(os.Getenv(arg))
+	}
+
+	return tokens
+}
+
+func commonPatterns(profile *model.Profile) map[string]string {
+	patterns := make(map[string]string)
+
+	for name := range profile.Tokens {
+		patterns[name] = ".*?"
+	}
+
+	patterns["profile"] = ".*?"
+	patterns["date"] = ".*?"
+	patterns["time"] = ".*?"
+	patterns["hostname"] = ".*?"
+	patterns["env"] = ".*?"
+
+	return patterns
+}
+
+// validateTokenNames makes sure every user defined token can be used in a
+// template and matched when looking for existing takes
+func validateTokenNames(profile *model.Profile) error {
+	for name := range profile.Tokens {
+		if !tokenNamePattern.MatchString(name) {
+			return errors.New("invalid token name: " + name + ". Token names may only contain letters, digits and underscores and must not start with a digit")
+		}
+	}
+
+	return nil
+}
+
+func applyTokenArgs(profile *model.Profile, args *model.CommandLineArgs) {
+	if profile.Tokens == nil {
+		profile.Tokens = make(map[string]string)
+	}
+
+	for name, value := range args.Tokens {
+		profile.Tokens[name] = value
+	}
+
+	if args.Event != "" {
+		profile.Tokens["event"] = args.Event
+	}
+
+	if args.Venue != "" {
+		profile.Tokens["venue"] = args.Venue
+	}
+
+	// the take start time is shared by every template so a session that
+	// crosses midnight doesn't end up split across directories
+	profile.Output.TakeTime = time.Now()
+}